@@ -0,0 +1,699 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// followInterval 持续输出时检查新日志的间隔
+const followInterval = 500 * time.Millisecond
+
+// rotatedNamePattern 轮转后的日志文件名，如 app-2024-01-02.log、app-2024-01-02-15.1.log.gz，第一个分组为文件名前缀
+var rotatedNamePattern = regexp.MustCompile(`^(.*-)\d{4}-\d{2}-\d{2}(?:-\d{2})?(?:\.\d+)?\.log(?:\.gz)?$`)
+
+// logsOptions logs 命令参数
+type logsOptions struct {
+	filter logFilter
+	pretty bool
+	tail   int
+	follow bool
+	name   string
+	stats  bool
+	top    int
+}
+
+// fieldFlags 可重复的 key=value 参数
+type fieldFlags map[string]string
+
+// String 实现 flag.Value 接口
+func (f fieldFlags) String() string {
+	pairs := make([]string, 0, len(f))
+	for k, v := range f {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set 实现 flag.Value 接口
+func (f fieldFlags) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[k] = v
+	return nil
+}
+
+// runLogs 执行 logs 命令
+func runLogs(args []string, out io.Writer) error {
+	opts := logsOptions{filter: logFilter{fields: make(fieldFlags)}}
+
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: calorie logs [flags] [path...]
+
+读取 JSON 格式的日志文件，path 可以是文件或目录（默认 logs），gzip 压缩的文件会自动解压。
+目录中的 *.log 和 *.log.gz 按修改时间顺序读取。
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	level := fs.String("level", "", "最低日志级别，如 warn 输出 warn、error 及以上")
+	since := fs.String("since", "", "起始时间，RFC3339 格式或相对时间如 30m、2h")
+	until := fs.String("until", "", "结束时间，格式同 -since")
+	fs.StringVar(&opts.filter.requestID, "request-id", "", "只输出指定请求ID的日志")
+	fs.Var(opts.filter.fields, "field", "字段过滤 key=value，可重复")
+	fs.StringVar(&opts.filter.message, "grep", "", "只输出消息包含该文本的日志")
+	fs.BoolVar(&opts.pretty, "pretty", false, "输出便于阅读的文本格式")
+	fs.IntVar(&opts.tail, "n", 0, "只输出最后 N 条匹配的日志")
+	fs.BoolVar(&opts.follow, "f", false, "持续输出新写入的日志")
+	fs.StringVar(&opts.name, "name", "app", "持续输出时跟踪的日志文件名前缀，与日志配置的 FileName 一致")
+	fs.BoolVar(&opts.stats, "stats", false, "输出统计信息：各级别数量、各路径错误数和 latency_ms 分位数")
+	fs.IntVar(&opts.top, "top", 10, "统计信息中显示的路径数量")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	var err error
+	if *level != "" {
+		if opts.filter.minLevel, err = logrus.ParseLevel(*level); err != nil {
+			return err
+		}
+		opts.filter.hasLevel = true
+	}
+	if opts.filter.since, err = parseTimeFlag(*since, now); err != nil {
+		return fmt.Errorf("invalid -since: %w", err)
+	}
+	if opts.filter.until, err = parseTimeFlag(*until, now); err != nil {
+		return fmt.Errorf("invalid -until: %w", err)
+	}
+	if opts.stats && opts.follow {
+		return fmt.Errorf("-stats cannot be used with -f")
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"logs"}
+	}
+	files, err := collectLogFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	var handle func(logRecord)
+	var finish func() error
+	switch {
+	case opts.stats:
+		stats := newLogStats()
+		handle = stats.add
+		finish = func() error { return stats.print(out, opts.top) }
+	case opts.tail > 0:
+		tail := newTailBuffer(opts.tail)
+		handle = tail.add
+		finish = func() error {
+			for _, rec := range tail.records() {
+				if err := printRecord(out, rec, opts.pretty); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		handle = func(rec logRecord) {
+			printRecord(out, rec, opts.pretty)
+		}
+		finish = func() error { return nil }
+	}
+
+	filtered := func(rec logRecord) {
+		if opts.filter.match(rec) {
+			handle(rec)
+		}
+	}
+
+	// 持续输出时跟踪的文件保持打开，最后读取，读到末尾后继续等待新写入的日志
+	var tailing *follower
+	if opts.follow {
+		if i := initialFollowFile(files, opts.name); i >= 0 {
+			tailing = &follower{path: files[i]}
+			files = append(files[:i:i], files[i+1:]...)
+		}
+	}
+	for _, file := range files {
+		if err := readLogFile(file, filtered); err != nil {
+			return err
+		}
+	}
+	if tailing != nil {
+		if err := tailing.read(filtered); err != nil {
+			return err
+		}
+	}
+	if err := finish(); err != nil || !opts.follow {
+		return err
+	}
+
+	if tailing == nil {
+		tailing = &follower{}
+	}
+	defer func() { tailing.close() }()
+	// 每个文件已读取的位置，切换回之前跟踪过的文件时从该位置继续读取
+	offsets := make(map[string]int64)
+	for {
+		time.Sleep(followInterval)
+
+		if err := tailing.read(func(rec logRecord) {
+			if opts.filter.match(rec) {
+				printRecord(out, rec, opts.pretty)
+			}
+		}); err != nil {
+			return err
+		}
+
+		// 日志轮转后切换到同一前缀的最新文件，同一目录下其他前缀的文件（如 error-*.log）不影响跟踪
+		files, err := collectLogFiles(paths)
+		if err != nil {
+			continue
+		}
+		if newest := newestFollowable(files, tailing.path); newest != "" && newest != tailing.path {
+			tailing.close()
+			if tailing.path != "" {
+				offsets[tailing.path] = tailing.offset
+			}
+			tailing = &follower{path: newest, offset: offsets[newest]}
+		}
+	}
+}
+
+// initialFollowFile 返回开始跟踪的文件序号：优先使用文件名前缀为 name 的最新未压缩文件，
+// 避免跟踪同一目录下 error-*.log 等其他输出目标的文件；没有时使用最新的未压缩文件，都没有时返回 -1
+func initialFollowFile(files []string, name string) int {
+	newest := -1
+	for i := len(files) - 1; i >= 0; i-- {
+		if strings.HasSuffix(files[i], ".gz") {
+			continue
+		}
+		if prefix, ok := logFilePrefix(files[i]); ok && filepath.Base(prefix) == name+"-" {
+			return i
+		}
+		if newest < 0 {
+			newest = i
+		}
+	}
+	return newest
+}
+
+// newestFollowable 返回与当前跟踪的文件前缀相同的最新未压缩文件，当前没有跟踪文件时返回最新的未压缩文件
+func newestFollowable(files []string, current string) string {
+	prefix, rotated := logFilePrefix(current)
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		if strings.HasSuffix(file, ".gz") {
+			continue
+		}
+		if current == "" || file == current {
+			return file
+		}
+		if p, ok := logFilePrefix(file); rotated && ok && p == prefix {
+			return file
+		}
+	}
+	return ""
+}
+
+// logFilePrefix 返回轮转后的日志文件的路径前缀，如 logs/app-2024-01-02.1.log 返回 logs/app-
+func logFilePrefix(path string) (string, bool) {
+	m := rotatedNamePattern.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(path), m[1]), true
+}
+
+// parseTimeFlag 解析时间参数，支持 RFC3339 和相对时间
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// collectLogFiles 展开目录，返回按修改时间排序的日志文件
+func collectLogFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		type logFile struct {
+			path    string
+			modTime time.Time
+		}
+		var dirFiles []logFile
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")) {
+				continue
+			}
+			fi, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			dirFiles = append(dirFiles, logFile{path: filepath.Join(path, name), modTime: fi.ModTime()})
+		}
+		sort.SliceStable(dirFiles, func(i, j int) bool {
+			if !dirFiles[i].modTime.Equal(dirFiles[j].modTime) {
+				return dirFiles[i].modTime.Before(dirFiles[j].modTime)
+			}
+			return dirFiles[i].path < dirFiles[j].path
+		})
+		for _, f := range dirFiles {
+			files = append(files, f.path)
+		}
+	}
+	return files, nil
+}
+
+// openLogFile 打开日志文件，gzip 压缩的文件自动解压
+func openLogFile(path string) (io.Reader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(f)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return gz, f, nil
+	}
+	return br, f, nil
+}
+
+// readLogFile 读取整个日志文件
+func readLogFile(path string, handle func(logRecord)) error {
+	r, closer, err := openLogFile(path)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	if err := scanRecords(r, handle); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// scanRecords 逐行解析日志，跳过非 JSON 行
+func scanRecords(r io.Reader, handle func(logRecord)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if rec, ok := parseRecord(scanner.Bytes()); ok {
+			handle(rec)
+		}
+	}
+	return scanner.Err()
+}
+
+// follower 持续读取正在写入的日志文件
+type follower struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64  // 已读取的完整行的字节数
+	pending []byte // 尚未写完的行
+}
+
+// read 读取到文件末尾，不完整的行留到下次读取
+func (f *follower) read(handle func(logRecord)) error {
+	if f.path == "" {
+		return nil
+	}
+	if f.file == nil {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+		f.file, f.reader = file, bufio.NewReader(file)
+	}
+
+	for {
+		line, err := f.reader.ReadBytes('\n')
+		f.pending = append(f.pending, line...)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		f.offset += int64(len(f.pending))
+		if rec, ok := parseRecord(f.pending); ok {
+			handle(rec)
+		}
+		f.pending = f.pending[:0]
+	}
+}
+
+// close 关闭文件
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// logRecord 一条 JSON 日志
+type logRecord map[string]interface{}
+
+// parseRecord 解析一行 JSON 日志
+func parseRecord(line []byte) (logRecord, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+
+	var rec logRecord
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rec); err != nil {
+		return nil, false
+	}
+	return rec, true
+}
+
+// str 获取字段的字符串形式
+func (r logRecord) str(key string) string {
+	v, ok := r[key]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// number 获取数值字段
+func (r logRecord) number(key string) (float64, bool) {
+	switch v := r[key].(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// level 获取日志级别
+func (r logRecord) level() (logrus.Level, bool) {
+	level, err := logrus.ParseLevel(r.str("level"))
+	return level, err == nil
+}
+
+// time 获取日志时间
+func (r logRecord) time() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, r.str("time"))
+	return t, err == nil
+}
+
+// logFilter 日志过滤条件
+type logFilter struct {
+	minLevel  logrus.Level
+	hasLevel  bool
+	since     time.Time
+	until     time.Time
+	requestID string
+	fields    fieldFlags
+	message   string
+}
+
+// match 判断日志是否满足过滤条件
+func (f *logFilter) match(rec logRecord) bool {
+	if f.hasLevel {
+		level, ok := rec.level()
+		if !ok || level > f.minLevel {
+			return false
+		}
+	}
+	if !f.since.IsZero() || !f.until.IsZero() {
+		t, ok := rec.time()
+		if !ok {
+			return false
+		}
+		if !f.since.IsZero() && t.Before(f.since) {
+			return false
+		}
+		if !f.until.IsZero() && t.After(f.until) {
+			return false
+		}
+	}
+	if f.requestID != "" && rec.str("request_id") != f.requestID {
+		return false
+	}
+	for k, v := range f.fields {
+		if rec.str(k) != v {
+			return false
+		}
+	}
+	if f.message != "" && !strings.Contains(rec.str("msg"), f.message) {
+		return false
+	}
+	return true
+}
+
+// printRecord 输出一条日志
+func printRecord(out io.Writer, rec logRecord, pretty bool) error {
+	if !pretty {
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+	}
+
+	keys := make([]string, 0, len(rec))
+	for k := range rec {
+		if k != "time" && k != "level" && k != "msg" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-7s %s", rec.str("time"), strings.ToUpper(rec.str("level")), rec.str("msg"))
+	for _, k := range keys {
+		v := rec.str(k)
+		if strings.ContainsAny(v, " \t\n\"") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// tailBuffer 保留最后 N 条日志
+type tailBuffer struct {
+	buf  []logRecord
+	next int
+	full bool
+}
+
+// newTailBuffer 创建容量为 n 的缓冲
+func newTailBuffer(n int) *tailBuffer {
+	return &tailBuffer{buf: make([]logRecord, n)}
+}
+
+// add 添加一条日志，超过容量时覆盖最早的日志
+func (t *tailBuffer) add(rec logRecord) {
+	t.buf[t.next] = rec
+	t.next = (t.next + 1) % len(t.buf)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// records 按时间顺序返回缓冲中的日志并清空
+func (t *tailBuffer) records() []logRecord {
+	var records []logRecord
+	if t.full {
+		records = append(records, t.buf[t.next:]...)
+	}
+	records = append(records, t.buf[:t.next]...)
+	t.next, t.full = 0, false
+	return records
+}
+
+// logStats 日志统计
+type logStats struct {
+	total        int
+	levels       map[string]int
+	errorsByPath map[string]int
+	latencies    map[string][]float64
+	allLatencies []float64
+}
+
+// newLogStats 创建日志统计
+func newLogStats() *logStats {
+	return &logStats{
+		levels:       make(map[string]int),
+		errorsByPath: make(map[string]int),
+		latencies:    make(map[string][]float64),
+	}
+}
+
+// add 统计一条日志
+// 错误指 error 及以上级别或状态码不小于 500 的日志，路径不含查询参数
+func (s *logStats) add(rec logRecord) {
+	s.total++
+	s.levels[rec.str("level")]++
+
+	path := rec.str("path")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	level, hasLevel := rec.level()
+	status, _ := rec.number("status")
+	if path != "" && ((hasLevel && level <= logrus.ErrorLevel) || status >= 500) {
+		s.errorsByPath[path]++
+	}
+
+	if latency, ok := rec.number("latency_ms"); ok {
+		s.allLatencies = append(s.allLatencies, latency)
+		if path != "" {
+			s.latencies[path] = append(s.latencies[path], latency)
+		}
+	}
+}
+
+// print 输出统计结果，路径按数量降序最多显示 top 个
+func (s *logStats) print(out io.Writer, top int) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "entries:\t%d\n", s.total)
+	levels := make([]string, 0, len(s.levels))
+	for level := range s.levels {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return s.levels[levels[i]] > s.levels[levels[j]]
+	})
+	for _, level := range levels {
+		name := level
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Fprintf(w, "  %s\t%d\n", name, s.levels[level])
+	}
+
+	fmt.Fprintln(w, "\nerrors by path:")
+	if len(s.errorsByPath) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	for _, path := range topKeys(s.errorsByPath, top) {
+		fmt.Fprintf(w, "  %s\t%d\n", path, s.errorsByPath[path])
+	}
+
+	fmt.Fprintln(w, "\nlatency_ms:")
+	if len(s.allLatencies) == 0 {
+		fmt.Fprintln(w, "  (no latency_ms field)")
+		return w.Flush()
+	}
+	fmt.Fprintln(w, "  path\tcount\tp50\tp90\tp95\tp99\tmax")
+	writeLatencyRow(w, "(all)", s.allLatencies)
+
+	counts := make(map[string]int, len(s.latencies))
+	for path, values := range s.latencies {
+		counts[path] = len(values)
+	}
+	for _, path := range topKeys(counts, top) {
+		writeLatencyRow(w, path, s.latencies[path])
+	}
+	return w.Flush()
+}
+
+// writeLatencyRow 输出一行延迟分位数
+func writeLatencyRow(w io.Writer, name string, values []float64) {
+	sort.Float64s(values)
+	fmt.Fprintf(w, "  %s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\n", name, len(values),
+		percentile(values, 50), percentile(values, 90), percentile(values, 95), percentile(values, 99),
+		values[len(values)-1])
+}
+
+// percentile 计算已排序数据的分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// topKeys 按值降序返回前 n 个键
+func topKeys(m map[string]int, n int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if n > 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
@@ -0,0 +1,46 @@
+// Command calorie provides operational tools for services built on calorie.
+//
+// Usage:
+//
+//	calorie logs [flags] [path...]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// usage 输出命令帮助
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: calorie <command> [arguments]
+
+Commands:
+  logs    查看、过滤和统计 JSON 格式的日志文件
+
+Run "calorie <command> -h" for more information about a command.`)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "logs":
+		err = runLogs(os.Args[2:], os.Stdout)
+	case "help", "-h", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "calorie: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "calorie %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
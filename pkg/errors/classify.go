@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+)
+
+// Kind 错误分类，由各客户端根据底层驱动的错误类型填充
+type Kind int
+
+const (
+	// KindUnknown 未分类
+	KindUnknown Kind = iota
+	// KindTimeout 超时
+	KindTimeout
+	// KindConflict 冲突，如唯一键冲突、写冲突、死锁
+	KindConflict
+	// KindNotFound 资源不存在
+	KindNotFound
+	// KindUnavailable 服务不可用，如连接被拒绝、连接断开
+	KindUnavailable
+)
+
+// String 返回分类名称
+func (k Kind) String() string {
+	switch k {
+	case KindTimeout:
+		return "timeout"
+	case KindConflict:
+		return "conflict"
+	case KindNotFound:
+		return "not_found"
+	case KindUnavailable:
+		return "unavailable"
+	default:
+		return "unknown"
+	}
+}
+
+// WithKind 设置错误分类
+func (e *Error) WithKind(kind Kind) *Error {
+	e.kind = kind
+	return e
+}
+
+// WithRetryable 显式设置是否可重试，优先于分类和错误码推断
+func (e *Error) WithRetryable(retryable bool) *Error {
+	e.retryable = &retryable
+	return e
+}
+
+// Kind 返回错误分类
+func (e *Error) Kind() Kind {
+	return e.kind
+}
+
+// Retryable 判断错误是否可重试
+// 1. 显式设置的结果优先
+// 2. 超时和服务不可用默认可重试，分类取自错误链
+// 3. 错误码在注册表中标记为可重试
+func (e *Error) Retryable() bool {
+	if e.retryable != nil {
+		return *e.retryable
+	}
+	if kind := KindOf(e); kind == KindTimeout || kind == KindUnavailable {
+		return true
+	}
+	if info, ok := DefaultRegistry.Lookup(e.Code); ok {
+		return info.Retryable
+	}
+	return false
+}
+
+// KindOf 获取错误链中的错误分类
+// 除 *Error 外，也识别 context.DeadlineExceeded 和实现了 Timeout() bool 的错误
+func KindOf(err error) Kind {
+	for err != nil {
+		if e, ok := err.(*Error); ok && e.kind != KindUnknown {
+			return e.kind
+		}
+		if err == context.DeadlineExceeded {
+			return KindTimeout
+		}
+		if t, ok := err.(interface{ Timeout() bool }); ok && t.Timeout() {
+			return KindTimeout
+		}
+		err = stderrors.Unwrap(err)
+	}
+	return KindUnknown
+}
+
+// IsRetryable 判断错误是否可重试
+// 错误链中第一个 *Error 决定结果；没有 *Error 时根据超时和 Temporary() 判断
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if e, ok := FromError(err); ok {
+		return e.Retryable()
+	}
+	if stderrors.Is(err, context.Canceled) {
+		return false
+	}
+	if KindOf(err) == KindTimeout {
+		return true
+	}
+	var t interface{ Temporary() bool }
+	return stderrors.As(err, &t) && t.Temporary()
+}
+
+// IsTimeout 判断是否为超时错误
+func IsTimeout(err error) bool {
+	return KindOf(err) == KindTimeout
+}
+
+// IsConflict 判断是否为冲突错误
+func IsConflict(err error) bool {
+	return KindOf(err) == KindConflict
+}
+
+// IsNotFound 判断是否为资源不存在错误
+func IsNotFound(err error) bool {
+	return KindOf(err) == KindNotFound
+}
+
+// IsUnavailable 判断是否为服务不可用错误
+func IsUnavailable(err error) bool {
+	return KindOf(err) == KindUnavailable
+}
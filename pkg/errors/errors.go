@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"strings"
 )
@@ -13,38 +14,103 @@ const (
 	CodeSuccess ErrorCode = 0
 	// CodeError 错误
 	CodeError ErrorCode = 1
+	// CodeInvalidParams 参数错误
+	CodeInvalidParams ErrorCode = 400
 	// CodeUnauthorized 未授权
 	CodeUnauthorized ErrorCode = 401
 	// CodeForbidden 禁止访问
 	CodeForbidden ErrorCode = 403
 	// CodeNotFound 资源不存在
 	CodeNotFound ErrorCode = 404
+	// CodeConflict 资源冲突
+	CodeConflict ErrorCode = 409
 	// CodeServerError 服务器错误
 	CodeServerError ErrorCode = 500
+	// CodeUnavailable 服务不可用
+	CodeUnavailable ErrorCode = 503
+	// CodeTimeout 操作超时
+	CodeTimeout ErrorCode = 504
 )
 
 // Error 自定义错误类型
 type Error struct {
-	Code    ErrorCode `json:"code"`    // 错误码
-	Message string    `json:"message"` // 错误消息
-	Details []string  `json:"details"` // 错误详情
+	Code    ErrorCode              `json:"code"`    // 错误码
+	Message string                 `json:"message"` // 错误消息
+	Details []string               `json:"details"` // 错误详情
+	Params  map[string]interface{} `json:"-"`       // 消息模板参数，用于多语言消息渲染
+
+	cause     error // 原始错误
+	stack     stack // 创建时的调用栈
+	kind      Kind  // 错误分类
+	retryable *bool // 是否可重试，为空时根据分类和错误码推断
 }
 
 // New 创建新的错误
 func New(code ErrorCode, message string, details ...string) *Error {
-	return &Error{
+	e := &Error{
 		Code:    code,
 		Message: message,
 		Details: details,
 	}
+	if captureStack.Load() {
+		e.stack = callers(3)
+	}
+	return e
+}
+
+// Wrap 包装已有错误，保留原始错误以便 errors.Is/As 判断
+// err 为 nil 时返回 nil
+func Wrap(err error, code ErrorCode, message string) *Error {
+	if err == nil {
+		return nil
+	}
+
+	e := &Error{
+		Code:    code,
+		Message: message,
+		cause:   err,
+	}
+	if captureStack.Load() {
+		e.stack = callers(3)
+	}
+	return e
 }
 
 // Error 实现 error 接口
 func (e *Error) Error() string {
+	msg := e.Message
 	if len(e.Details) > 0 {
-		return fmt.Sprintf("%s: %s", e.Message, strings.Join(e.Details, "; "))
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(e.Details, "; "))
 	}
-	return e.Message
+	if e.cause != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.cause)
+	}
+	return msg
+}
+
+// WithParam 添加消息模板参数
+func (e *Error) WithParam(key string, value interface{}) *Error {
+	if e.Params == nil {
+		e.Params = make(map[string]interface{})
+	}
+	e.Params[key] = value
+	return e
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithStack 记录当前调用栈，不受 SetStackCapture 开关影响
+func (e *Error) WithStack() *Error {
+	e.stack = callers(3)
+	return e
+}
+
+// StackTrace 返回创建时记录的调用栈，未记录时返回空字符串
+func (e *Error) StackTrace() string {
+	return e.stack.String()
 }
 
 // WithDetails 添加错误详情
@@ -65,19 +131,49 @@ func (e *Error) Is(target error) bool {
 }
 
 // DefaultErrorMessages 默认错误消息映射
+//
+// Deprecated: 使用 RegisterModule 注册错误码，注册表中的消息优先于此映射
 var DefaultErrorMessages = map[ErrorCode]string{
-	CodeSuccess:      "success",
-	CodeError:        "error",
-	CodeUnauthorized: "unauthorized",
-	CodeForbidden:    "forbidden",
-	CodeNotFound:     "not found",
-	CodeServerError:  "server error",
+	CodeSuccess:       "success",
+	CodeError:         "error",
+	CodeInvalidParams: "invalid params",
+	CodeUnauthorized:  "unauthorized",
+	CodeForbidden:     "forbidden",
+	CodeNotFound:      "not found",
+	CodeConflict:      "conflict",
+	CodeServerError:   "server error",
+	CodeUnavailable:   "service unavailable",
+	CodeTimeout:       "timeout",
 }
 
 // GetMessage 获取错误消息
 func GetMessage(code ErrorCode) string {
+	if info, ok := DefaultRegistry.Lookup(code); ok && info.Message != "" {
+		return info.Message
+	}
 	if msg, ok := DefaultErrorMessages[code]; ok {
 		return msg
 	}
 	return "unknown error"
 }
+
+// FromError 从错误链中获取 *Error
+func FromError(err error) (*Error, bool) {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// Stack 返回错误链中最内层记录的调用栈，即最接近错误发生处的调用栈
+func Stack(err error) string {
+	var trace string
+	for err != nil {
+		if e, ok := err.(*Error); ok && len(e.stack) > 0 {
+			trace = e.stack.String()
+		}
+		err = stderrors.Unwrap(err)
+	}
+	return trace
+}
@@ -0,0 +1,230 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Bundle 多语言错误消息包
+// 每种语言一个消息文件，文件名即语言标签，如 zh-CN.json、en.json
+// 文件内容为错误码到消息模板的映射：
+//
+//	{
+//	  "404": "资源不存在",
+//	  "10001": "用户 {{.name}} 已存在"
+//	}
+//
+// 模板参数来自 Error.Params，另外可以通过 .Code 和 .Details 访问错误码和错误详情
+// 模板引用的参数缺失时视为未找到消息，由调用方回退到错误自带的消息，避免输出 "<no value>"
+type Bundle struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[ErrorCode]*template.Template
+}
+
+// NewBundle 创建多语言错误消息包
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: defaultLocale,
+		messages:      make(map[string]map[ErrorCode]*template.Template),
+	}
+}
+
+// DefaultLocale 返回默认语言
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Locales 返回已加载的语言列表
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.sortedLocales()
+}
+
+// AddMessages 添加某种语言的消息模板
+func (b *Bundle) AddMessages(locale string, messages map[ErrorCode]string) error {
+	templates := make(map[ErrorCode]*template.Template, len(messages))
+	for code, text := range messages {
+		tmpl, err := template.New(strconv.Itoa(int(code))).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("errors: invalid message template for code %d in locale %q: %w", code, locale, err)
+		}
+		templates[code] = tmpl
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[ErrorCode]*template.Template, len(templates))
+	}
+	for code, tmpl := range templates {
+		b.messages[locale][code] = tmpl
+	}
+	return nil
+}
+
+// LoadFile 加载消息文件，语言标签取自文件名
+func (b *Bundle) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("errors: parse message file %s: %w", path, err)
+	}
+
+	messages := make(map[ErrorCode]string, len(raw))
+	for key, text := range raw {
+		code, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf("errors: invalid code %q in message file %s", key, path)
+		}
+		messages[ErrorCode(code)] = text
+	}
+
+	locale := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return b.AddMessages(locale, messages)
+}
+
+// LoadDir 加载目录下所有 .json 消息文件
+func (b *Bundle) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := b.LoadFile(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match 根据 Accept-Language 选择最匹配的已加载语言，没有匹配时返回默认语言
+// 按 q 值从高到低依次尝试，每个语言标签先精确匹配，再按主语言匹配，如 zh 匹配 zh-CN
+func (b *Bundle) Match(acceptLanguage string) string {
+	tags := parseAcceptLanguage(acceptLanguage)
+	if len(tags) == 0 {
+		return b.defaultLocale
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	locales := b.sortedLocales()
+	for _, tag := range tags {
+		for _, locale := range locales {
+			if strings.EqualFold(locale, tag) {
+				return locale
+			}
+		}
+		base := baseLanguage(tag)
+		for _, locale := range locales {
+			if strings.EqualFold(baseLanguage(locale), base) {
+				return locale
+			}
+		}
+	}
+	return b.defaultLocale
+}
+
+// sortedLocales 返回排序后的语言列表，保证匹配结果稳定，调用方需持有读锁
+func (b *Bundle) sortedLocales() []string {
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Message 获取指定语言下错误码的消息，未找到时回退到默认语言
+func (b *Bundle) Message(locale string, code ErrorCode, data map[string]interface{}) (string, bool) {
+	b.mu.RLock()
+	tmpl, ok := b.messages[locale][code]
+	if !ok {
+		tmpl, ok = b.messages[b.defaultLocale][code]
+	}
+	b.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// Localize 获取错误在指定语言下的消息
+func (b *Bundle) Localize(locale string, e *Error) (string, bool) {
+	data := make(map[string]interface{}, len(e.Params)+2)
+	for k, v := range e.Params {
+		data[k] = v
+	}
+	data["Code"] = e.Code
+	data["Details"] = e.Details
+	return b.Message(locale, e.Code, data)
+}
+
+// parseAcceptLanguage 解析 Accept-Language，按 q 值从高到低返回语言标签
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var items []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		items = append(items, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+
+	tags := make([]string, len(items))
+	for i, item := range items {
+		tags[i] = item.tag
+	}
+	return tags
+}
+
+// baseLanguage 获取主语言，如 zh-CN -> zh
+func baseLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
@@ -0,0 +1,209 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodeInfo 错误码信息
+type CodeInfo struct {
+	Code       ErrorCode `json:"code"`                  // 错误码
+	Module     string    `json:"module"`                // 所属模块
+	Message    string    `json:"message"`               // 默认错误消息
+	HTTPStatus int       `json:"http_status,omitempty"` // 对应的 HTTP 状态码，为 0 时由使用方按回退规则确定
+	Retryable  bool      `json:"retryable"`             // 是否可重试
+}
+
+// Registry 错误码注册表
+// 每个模块先注册自己的错误码段，再在段内注册错误码，重复注册会返回错误
+type Registry struct {
+	mu      sync.RWMutex
+	modules map[string]*Module
+	codes   map[ErrorCode]CodeInfo
+}
+
+// Module 模块错误码段
+type Module struct {
+	Name string    // 模块名称
+	Min  ErrorCode // 错误码下限（包含）
+	Max  ErrorCode // 错误码上限（包含）
+
+	registry *Registry
+}
+
+// NewRegistry 创建新的错误码注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		modules: make(map[string]*Module),
+		codes:   make(map[ErrorCode]CodeInfo),
+	}
+}
+
+// RegisterModule 注册模块错误码段
+// 模块名称重复或错误码段与已有模块重叠时返回错误
+func (r *Registry) RegisterModule(name string, min, max ErrorCode) (*Module, error) {
+	if name == "" {
+		return nil, fmt.Errorf("errors: module name is empty")
+	}
+	if min > max {
+		return nil, fmt.Errorf("errors: module %q has invalid range [%d, %d]", name, min, max)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.modules[name]; ok {
+		return nil, fmt.Errorf("errors: module %q already registered", name)
+	}
+	for _, m := range r.modules {
+		if min <= m.Max && m.Min <= max {
+			return nil, fmt.Errorf("errors: module %q range [%d, %d] overlaps module %q range [%d, %d]",
+				name, min, max, m.Name, m.Min, m.Max)
+		}
+	}
+
+	m := &Module{
+		Name:     name,
+		Min:      min,
+		Max:      max,
+		registry: r,
+	}
+	r.modules[name] = m
+	return m, nil
+}
+
+// MustRegisterModule 注册模块错误码段，失败时 panic
+func (r *Registry) MustRegisterModule(name string, min, max ErrorCode) *Module {
+	m, err := r.RegisterModule(name, min, max)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Register 在模块错误码段内注册错误码
+// 错误码超出模块范围或已被注册时返回错误
+func (m *Module) Register(info CodeInfo) error {
+	if info.Code < m.Min || info.Code > m.Max {
+		return fmt.Errorf("errors: code %d out of module %q range [%d, %d]", info.Code, m.Name, m.Min, m.Max)
+	}
+
+	r := m.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if exist, ok := r.codes[info.Code]; ok {
+		return fmt.Errorf("errors: code %d already registered by module %q", info.Code, exist.Module)
+	}
+
+	info.Module = m.Name
+	r.codes[info.Code] = info
+	return nil
+}
+
+// MustRegister 注册错误码，失败时 panic，返回注册的错误码便于在变量声明中使用
+//
+//	var CodeUserExists = userModule.MustRegister(errors.CodeInfo{
+//		Code:       10001,
+//		Message:    "user already exists",
+//		HTTPStatus: http.StatusConflict,
+//	})
+func (m *Module) MustRegister(info CodeInfo) ErrorCode {
+	if err := m.Register(info); err != nil {
+		panic(err)
+	}
+	return info.Code
+}
+
+// Lookup 查询错误码信息
+func (r *Registry) Lookup(code ErrorCode) (CodeInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.codes[code]
+	return info, ok
+}
+
+// Catalog 返回按错误码排序的全部错误码信息
+func (r *Registry) Catalog() []CodeInfo {
+	r.mu.RLock()
+	catalog := make([]CodeInfo, 0, len(r.codes))
+	for _, info := range r.codes {
+		catalog = append(catalog, info)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Code < catalog[j].Code
+	})
+	return catalog
+}
+
+// WriteJSON 以 JSON 格式导出错误码目录
+func (r *Registry) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.Catalog())
+}
+
+// WriteMarkdown 以 Markdown 表格格式导出错误码目录
+func (r *Registry) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Code | Module | HTTP Status | Retryable | Message |\n")
+	b.WriteString("| ---- | ------ | ----------- | --------- | ------- |\n")
+	for _, info := range r.Catalog() {
+		status := "-"
+		if info.HTTPStatus != 0 {
+			status = strconv.Itoa(info.HTTPStatus)
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %t | %s |\n",
+			info.Code, info.Module, status, info.Retryable,
+			strings.ReplaceAll(info.Message, "|", "\\|"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// DefaultRegistry 默认错误码注册表，内置错误码注册在 core 模块（0-999）
+var DefaultRegistry = NewRegistry()
+
+// coreModule 内置错误码模块
+var coreModule = DefaultRegistry.MustRegisterModule("core", 0, 999)
+
+func init() {
+	coreModule.MustRegister(CodeInfo{Code: CodeSuccess, Message: "success", HTTPStatus: http.StatusOK})
+	coreModule.MustRegister(CodeInfo{Code: CodeError, Message: "error", HTTPStatus: http.StatusBadRequest})
+	coreModule.MustRegister(CodeInfo{Code: CodeInvalidParams, Message: "invalid params", HTTPStatus: http.StatusBadRequest})
+	coreModule.MustRegister(CodeInfo{Code: CodeUnauthorized, Message: "unauthorized", HTTPStatus: http.StatusUnauthorized})
+	coreModule.MustRegister(CodeInfo{Code: CodeForbidden, Message: "forbidden", HTTPStatus: http.StatusForbidden})
+	coreModule.MustRegister(CodeInfo{Code: CodeNotFound, Message: "not found", HTTPStatus: http.StatusNotFound})
+	coreModule.MustRegister(CodeInfo{Code: CodeConflict, Message: "conflict", HTTPStatus: http.StatusConflict})
+	coreModule.MustRegister(CodeInfo{Code: CodeServerError, Message: "server error", HTTPStatus: http.StatusInternalServerError})
+	coreModule.MustRegister(CodeInfo{Code: CodeUnavailable, Message: "service unavailable", HTTPStatus: http.StatusServiceUnavailable, Retryable: true})
+	coreModule.MustRegister(CodeInfo{Code: CodeTimeout, Message: "timeout", HTTPStatus: http.StatusGatewayTimeout, Retryable: true})
+}
+
+// RegisterModule 在默认注册表中注册模块错误码段
+func RegisterModule(name string, min, max ErrorCode) (*Module, error) {
+	return DefaultRegistry.RegisterModule(name, min, max)
+}
+
+// MustRegisterModule 在默认注册表中注册模块错误码段，失败时 panic
+func MustRegisterModule(name string, min, max ErrorCode) *Module {
+	return DefaultRegistry.MustRegisterModule(name, min, max)
+}
+
+// Lookup 在默认注册表中查询错误码信息
+func Lookup(code ErrorCode) (CodeInfo, bool) {
+	return DefaultRegistry.Lookup(code)
+}
+
+// Catalog 返回默认注册表的错误码目录
+func Catalog() []CodeInfo {
+	return DefaultRegistry.Catalog()
+}
@@ -0,0 +1,75 @@
+package errors
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// 最大尝试次数（包含第一次）
+	MaxAttempts int
+	// 首次重试前的等待时间
+	InitialBackoff time.Duration
+	// 最大等待时间
+	MaxBackoff time.Duration
+	// 等待时间增长倍数
+	Multiplier float64
+	// 是否在等待时间上增加随机抖动
+	Jitter bool
+	// 判断错误是否可重试，为空时使用 IsRetryable
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy 默认重试策略
+var DefaultRetryPolicy = &RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         true,
+}
+
+// Retry 按策略执行 fn，遇到可重试错误时等待后重试
+// 返回最后一次执行的错误；ctx 取消时立即返回
+func Retry(ctx context.Context, policy *RetryPolicy, fn func(ctx context.Context) error) error {
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	backoff := policy.InitialBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := backoff
+		if policy.Jitter && wait > 0 {
+			wait = time.Duration(rand.Int63n(int64(wait)) + 1)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if policy.Multiplier > 1 {
+			backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		}
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// maxStackDepth 最大调用栈深度
+const maxStackDepth = 32
+
+// captureStack 是否在创建错误时记录调用栈
+var captureStack atomic.Bool
+
+// SetStackCapture 设置是否在 New/Wrap 时自动记录调用栈
+// 记录调用栈有一定开销，建议仅在开发或排障时开启
+func SetStackCapture(enabled bool) {
+	captureStack.Store(enabled)
+}
+
+// StackCaptureEnabled 是否已开启调用栈记录
+func StackCaptureEnabled() bool {
+	return captureStack.Load()
+}
+
+// stack 调用栈
+type stack []uintptr
+
+// callers 获取调用栈，skip 为需要跳过的栈帧数
+func callers(skip int) stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip, pcs[:])
+	st := make(stack, n)
+	copy(st, pcs[:n])
+	return st
+}
+
+// String 格式化调用栈
+func (s stack) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，如 user.emails[0]
+	Rule    string `json:"rule"`    // 校验规则，如 required
+	Message string `json:"message"` // 错误消息
+}
+
+// ValidationError 参数校验错误，汇总多个字段错误
+type ValidationError struct {
+	Fields []FieldError `json:"fields"` // 字段错误列表
+}
+
+// NewValidation 创建参数校验错误
+func NewValidation(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// Add 添加字段错误
+func (v *ValidationError) Add(field, rule, message string) *ValidationError {
+	v.Fields = append(v.Fields, FieldError{
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+	})
+	return v
+}
+
+// HasErrors 是否存在字段错误
+func (v *ValidationError) HasErrors() bool {
+	return len(v.Fields) > 0
+}
+
+// ErrOrNil 存在字段错误时返回自身，否则返回 nil
+// 用于避免把空的 *ValidationError 作为非 nil 的 error 返回
+func (v *ValidationError) ErrOrNil() error {
+	if v == nil || !v.HasErrors() {
+		return nil
+	}
+	return v
+}
+
+// Error 实现 error 接口
+func (v *ValidationError) Error() string {
+	parts := make([]string, 0, len(v.Fields))
+	for _, f := range v.Fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+	return fmt.Sprintf("%s: %s", GetMessage(CodeInvalidParams), strings.Join(parts, "; "))
+}
+
+// ToError 转换为 CodeInvalidParams 错误，原校验错误作为其原始错误保留
+func (v *ValidationError) ToError() *Error {
+	return Wrap(v, CodeInvalidParams, GetMessage(CodeInvalidParams))
+}
@@ -0,0 +1,298 @@
+package gin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/NHYCRaymond/calorie/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// APIKeyContextKey gin.Context 中保存 API Key 信息的键
+const APIKeyContextKey = "calorie.apikey"
+
+// ErrAPIKeyNotFound API Key 不存在，APIKeyStore 查找不到时返回，可以用 %w 包装
+// 使用普通的哨兵错误按实例比较，存储返回的其他 errors.CodeNotFound 错误不会被当作密钥不存在
+var ErrAPIKeyNotFound = stderrors.New("api key not found")
+
+// APIKey API Key 信息，不包含密钥本身
+type APIKey struct {
+	ID        string    // 键ID，用于日志和指标
+	Owner     string    // 所属的调用方
+	Scopes    []string  // 权限范围
+	ExpiresAt time.Time // 过期时间，零值表示不过期
+	Disabled  bool      // 是否已停用
+}
+
+// HasScope 判断是否拥有权限范围
+func (k *APIKey) HasScope(scope string) bool {
+	return containsString(k.Scopes, scope)
+}
+
+// Expired 判断是否已过期
+func (k *APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
+
+// APIKeyStore API Key 存储，按密钥的哈希值查找
+// 不存在时返回 ErrAPIKeyNotFound，实现需要是协程安全的
+type APIKeyStore interface {
+	Lookup(ctx context.Context, hash string) (*APIKey, error)
+}
+
+// HashAPIKey 计算 API Key 的哈希值（SHA-256 十六进制），存储中只保存哈希值
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyConfig API Key 认证配置
+type APIKeyConfig struct {
+	// API Key 存储
+	Store APIKeyStore
+	// 读取 API Key 的请求头，默认 X-API-Key
+	Header string
+	// 读取 API Key 的查询参数，为空时不从查询参数读取
+	// 注意：查询参数会出现在访问日志和代理日志中，仅在无法使用请求头时开启
+	Query string
+	// 哈希函数，默认 HashAPIKey
+	Hash func(key string) string
+	// 查找结果的缓存时间，默认 1 分钟，小于 0 时不缓存
+	CacheTTL time.Duration
+	// 查找不到时的缓存时间，默认 10 秒，小于 0 时不缓存
+	NegativeCacheTTL time.Duration
+	// 缓存的最大条数，默认 10000
+	CacheSize int
+	// 监控客户端，不为 nil 时按键记录请求数和认证失败次数
+	Metrics *metrics.Client
+	// 服务名称，用于指标标签
+	ServiceName string
+}
+
+// APIKeyAuth API Key 认证
+type APIKeyAuth struct {
+	config APIKeyConfig
+	cache  *apiKeyCache
+
+	requests *prometheus.CounterVec
+	failures *prometheus.CounterVec
+}
+
+// NewAPIKeyAuth 创建 API Key 认证
+func NewAPIKeyAuth(config *APIKeyConfig) (*APIKeyAuth, error) {
+	if config == nil || config.Store == nil {
+		return nil, fmt.Errorf("apikey: store is nil")
+	}
+
+	cfg := *config
+	if cfg.Header == "" {
+		cfg.Header = "X-API-Key"
+	}
+	if cfg.Hash == nil {
+		cfg.Hash = HashAPIKey
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = time.Minute
+	}
+	if cfg.NegativeCacheTTL == 0 {
+		cfg.NegativeCacheTTL = 10 * time.Second
+	}
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = 10000
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "default"
+	}
+
+	a := &APIKeyAuth{config: cfg}
+	if cfg.CacheTTL > 0 || cfg.NegativeCacheTTL > 0 {
+		a.cache = newAPIKeyCache(cfg.CacheSize)
+	}
+	if cfg.Metrics != nil {
+		a.requests = cfg.Metrics.Counter(
+			"api_key_requests_total",
+			"Total number of requests authenticated by API key",
+			[]string{"service", "key_id", "owner"},
+		)
+		a.failures = cfg.Metrics.Counter(
+			"api_key_auth_failures_total",
+			"Total number of failed API key authentications",
+			[]string{"service", "reason"},
+		)
+	}
+	return a, nil
+}
+
+// Lookup 查找 API Key，优先使用缓存
+func (a *APIKeyAuth) Lookup(ctx context.Context, key string) (*APIKey, error) {
+	hash := a.config.Hash(key)
+	now := time.Now()
+	if a.cache != nil {
+		if k, found, ok := a.cache.get(hash, now); ok {
+			if !found {
+				return nil, ErrAPIKeyNotFound
+			}
+			return k, nil
+		}
+	}
+
+	k, err := a.config.Store.Lookup(ctx, hash)
+	switch {
+	case err == nil:
+		if a.cache != nil && a.config.CacheTTL > 0 {
+			a.cache.set(hash, k, now.Add(a.config.CacheTTL))
+		}
+		return k, nil
+	case stderrors.Is(err, ErrAPIKeyNotFound):
+		if a.cache != nil && a.config.NegativeCacheTTL > 0 {
+			a.cache.set(hash, nil, now.Add(a.config.NegativeCacheTTL))
+		}
+		return nil, ErrAPIKeyNotFound
+	default:
+		return nil, err
+	}
+}
+
+// Invalidate 删除 API Key 的缓存，停用或轮换密钥后调用可以立即生效
+func (a *APIKeyAuth) Invalidate(key string) {
+	if a.cache != nil {
+		a.cache.delete(a.config.Hash(key))
+	}
+}
+
+// key 按配置的来源读取 API Key
+func (a *APIKeyAuth) key(c *gin.Context) string {
+	if key := c.GetHeader(a.config.Header); key != "" {
+		return key
+	}
+	if a.config.Query != "" {
+		return c.Query(a.config.Query)
+	}
+	return ""
+}
+
+// Middleware 返回 API Key 认证中间件
+// 认证通过后 API Key 信息保存在 gin.Context 中，通过 APIKeyFrom 获取，键ID和调用方同时写入日志字段；
+// 缺少或无效的 API Key 返回 errors.CodeUnauthorized，存储不可用时返回 errors.CodeUnavailable
+func (a *APIKeyAuth) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := a.key(c)
+		if raw == "" {
+			a.fail(c, "missing", errors.New(errors.CodeUnauthorized, "missing api key"))
+			return
+		}
+
+		key, err := a.Lookup(c.Request.Context(), raw)
+		switch {
+		case stderrors.Is(err, ErrAPIKeyNotFound):
+			a.fail(c, "invalid", errors.New(errors.CodeUnauthorized, "invalid api key"))
+			return
+		case err != nil:
+			logger.Ctx(c).WithError(err).Warn("API Key 查找失败")
+			a.fail(c, "error", errors.Wrap(err, errors.CodeUnavailable, "api key store unavailable"))
+			return
+		case key.Disabled:
+			a.fail(c, "disabled", errors.New(errors.CodeUnauthorized, "invalid api key"))
+			return
+		case key.Expired(time.Now()):
+			a.fail(c, "expired", errors.New(errors.CodeUnauthorized, "api key expired"))
+			return
+		}
+
+		if a.requests != nil {
+			a.requests.WithLabelValues(a.config.ServiceName, key.ID, key.Owner).Inc()
+		}
+		c.Set(APIKeyContextKey, key)
+		AddLogFields(c, logger.Fields{"api_key_id": key.ID, "api_key_owner": key.Owner})
+		c.Next()
+	}
+}
+
+// fail 记录认证失败并返回错误
+func (a *APIKeyAuth) fail(c *gin.Context, reason string, e *errors.Error) {
+	if a.failures != nil {
+		a.failures.WithLabelValues(a.config.ServiceName, reason).Inc()
+	}
+	abortWithError(c, e)
+}
+
+// APIKeyFrom 获取 API Key 认证中间件保存的 API Key 信息
+func APIKeyFrom(c *gin.Context) (*APIKey, bool) {
+	v, ok := c.Get(APIKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	key, ok := v.(*APIKey)
+	return key, ok
+}
+
+// apiKeyCache API Key 查找结果的缓存，key 为 nil 表示查找不到
+type apiKeyCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]apiKeyCacheEntry
+}
+
+// apiKeyCacheEntry 缓存项
+type apiKeyCacheEntry struct {
+	key     *APIKey
+	expires time.Time
+}
+
+// newAPIKeyCache 创建缓存
+func newAPIKeyCache(size int) *apiKeyCache {
+	return &apiKeyCache{size: size, entries: make(map[string]apiKeyCacheEntry)}
+}
+
+// get 获取缓存，ok 表示缓存命中，found 表示 API Key 存在
+func (c *apiKeyCache) get(hash string, now time.Time) (key *APIKey, found, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[hash]
+	if !ok {
+		return nil, false, false
+	}
+	if now.After(entry.expires) {
+		delete(c.entries, hash)
+		return nil, false, false
+	}
+	return entry.key, entry.key != nil, true
+}
+
+// set 写入缓存，超过容量时先清理过期项，仍然超过时随机淘汰一项
+func (c *apiKeyCache) set(hash string, key *APIKey, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[hash]; !ok && len(c.entries) >= c.size {
+		now := time.Now()
+		for h, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, h)
+			}
+		}
+		for h := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, h)
+		}
+	}
+	c.entries[hash] = apiKeyCacheEntry{key: key, expires: expires}
+}
+
+// delete 删除缓存
+func (c *apiKeyCache) delete(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, hash)
+}
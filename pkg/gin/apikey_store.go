@@ -0,0 +1,111 @@
+package gin
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/mysql"
+	"github.com/NHYCRaymond/calorie/pkg/redis"
+)
+
+// RedisAPIKeyStore 基于 Redis 的 API Key 存储
+// 每个 API Key 保存为一个哈希，键为 prefix + 哈希值，字段如下：
+//
+//	id          键ID
+//	owner       所属的调用方
+//	scopes      权限范围，空格分隔
+//	expires_at  过期时间，Unix 秒，为空或 0 表示不过期
+//	disabled    为 "1" 或 "true" 时表示已停用
+type RedisAPIKeyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisAPIKeyStore 创建基于 Redis 的 API Key 存储，prefix 默认为 "apikey:"
+func NewRedisAPIKeyStore(client *redis.Client, prefix string) *RedisAPIKeyStore {
+	if prefix == "" {
+		prefix = "apikey:"
+	}
+	return &RedisAPIKeyStore{client: client, prefix: prefix}
+}
+
+// Lookup 按哈希值查找 API Key
+func (s *RedisAPIKeyStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	fields, err := s.client.HGetAll(ctx, s.prefix+hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	key := &APIKey{
+		ID:       fields["id"],
+		Owner:    fields["owner"],
+		Scopes:   strings.Fields(fields["scopes"]),
+		Disabled: fields["disabled"] == "1" || strings.EqualFold(fields["disabled"], "true"),
+	}
+	if v := fields["expires_at"]; v != "" {
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("apikey: invalid expires_at %q: %w", v, err)
+		}
+		if sec > 0 {
+			key.ExpiresAt = time.Unix(sec, 0)
+		}
+	}
+	return key, nil
+}
+
+// MySQLAPIKeyStore 基于 MySQL 的 API Key 存储，表结构如下：
+//
+//	CREATE TABLE api_keys (
+//	    id         VARCHAR(64)  NOT NULL PRIMARY KEY,
+//	    key_hash   CHAR(64)     NOT NULL UNIQUE,
+//	    owner      VARCHAR(128) NOT NULL,
+//	    scopes     VARCHAR(1024) NOT NULL DEFAULT '',  -- 空格分隔
+//	    expires_at DATETIME     NULL,                  -- NULL 表示不过期
+//	    disabled   TINYINT(1)   NOT NULL DEFAULT 0
+//	);
+type MySQLAPIKeyStore struct {
+	client *mysql.Client
+	query  string
+}
+
+// NewMySQLAPIKeyStore 创建基于 MySQL 的 API Key 存储，table 默认为 "api_keys"
+func NewMySQLAPIKeyStore(client *mysql.Client, table string) *MySQLAPIKeyStore {
+	if table == "" {
+		table = "api_keys"
+	}
+	return &MySQLAPIKeyStore{
+		client: client,
+		query:  "SELECT id, owner, scopes, UNIX_TIMESTAMP(expires_at), disabled FROM " + table + " WHERE key_hash = ?",
+	}
+}
+
+// Lookup 按哈希值查找 API Key
+func (s *MySQLAPIKeyStore) Lookup(ctx context.Context, hash string) (*APIKey, error) {
+	var (
+		key       APIKey
+		scopes    string
+		expiresAt sql.NullFloat64
+	)
+	err := s.client.QueryRow(ctx, s.query, hash).Scan(&key.ID, &key.Owner, &scopes, &expiresAt, &key.Disabled)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, mysql.WrapError(err, "query_row")
+	}
+
+	key.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid && expiresAt.Float64 > 0 {
+		key.ExpiresAt = time.Unix(int64(expiresAt.Float64), 0)
+	}
+	return &key, nil
+}
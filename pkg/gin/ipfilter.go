@@ -0,0 +1,389 @@
+package gin
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIPKey gin.Context 中保存解析后的客户端 IP 的键
+const ClientIPKey = "client_ip"
+
+// DefaultProxyHeaders 默认从这些请求头中解析客户端 IP，按顺序使用第一个有效的值
+var DefaultProxyHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// ClientIP 获取客户端 IP
+// 优先使用 RequestMiddleware 或 IPFilter 按受信任代理解析后写入上下文的值，否则使用直连地址；
+// 不使用 c.ClientIP()，gin 默认信任所有代理，客户端可以通过 X-Forwarded-For 伪造地址
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString(ClientIPKey); ip != "" {
+		return ip
+	}
+	return peerIP(c.Request)
+}
+
+// TrustedProxies 受信任的代理，只有来自受信任代理的请求才会使用代理请求头中的客户端 IP
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+	headers  []string
+}
+
+// NewTrustedProxies 创建受信任的代理，cidrs 为代理的 IP 或网段，支持 IPv4 和 IPv6
+// headers 为空时使用 DefaultProxyHeaders
+func NewTrustedProxies(cidrs []string, headers ...string) (*TrustedProxies, error) {
+	prefixes, err := parsePrefixes(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		headers = DefaultProxyHeaders
+	}
+	return &TrustedProxies{prefixes: prefixes, headers: headers}, nil
+}
+
+// ClientIP 解析请求的客户端 IP
+// 直连地址不是受信任代理时直接返回直连地址；X-Forwarded-For 合并所有行后从右向左跳过受信任代理，
+// 返回第一个不受信任的地址，全部受信任时返回最左侧的地址，最右侧的地址无法解析时返回直连地址
+func (p *TrustedProxies) ClientIP(r *http.Request) string {
+	remote, ok := parseAddr(remoteHost(r.RemoteAddr))
+	if !ok {
+		return remoteHost(r.RemoteAddr)
+	}
+	if !containsAddr(p.prefixes, remote) {
+		return remote.String()
+	}
+
+	for _, header := range p.headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+		if !strings.EqualFold(header, "X-Forwarded-For") {
+			// 单值请求头出现多次时无法判断哪个由代理写入，不使用
+			if len(values) > 1 {
+				continue
+			}
+			if addr, ok := parseAddr(values[0]); ok {
+				return addr.String()
+			}
+			continue
+		}
+
+		// 代理可能追加到已有的行，也可能新增一行，合并所有行后从右向左处理
+		hops := strings.Split(strings.Join(values, ","), ",")
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, ok := parseAddr(hops[i])
+			if !ok {
+				break
+			}
+			client = addr.String()
+			if !containsAddr(p.prefixes, addr) {
+				break
+			}
+		}
+		// 最右侧的地址无法解析时不再信任其他代理请求头，使用直连地址
+		if client == "" {
+			return remote.String()
+		}
+		return client
+	}
+	return remote.String()
+}
+
+// IPFilterConfig IP 访问控制配置
+type IPFilterConfig struct {
+	// 允许访问的 IP 或网段，不为空时只允许这些地址访问
+	Allow []string
+	// 拒绝访问的 IP 或网段，优先于 Allow
+	Deny []string
+	// 访问控制列表文件，修改后自动重新加载，与 Allow/Deny 合并生效
+	// 每行一条规则，格式为 "allow <CIDR>" 或 "deny <CIDR>"，省略动作时为 allow，# 开头为注释
+	File string
+	// 检查文件修改的间隔，默认 10 秒
+	ReloadInterval time.Duration
+	// 受信任代理的 IP 或网段，只信任来自这些代理的代理请求头；
+	// 为空时使用 ClientIP 获取客户端 IP，即 RequestMiddleware 解析的结果或直连地址
+	TrustedProxies []string
+	// 解析客户端 IP 的请求头，为空时使用 DefaultProxyHeaders
+	ProxyHeaders []string
+}
+
+// ipLists 生效的访问控制列表
+type ipLists struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// IPFilter IP 访问控制
+type IPFilter struct {
+	config  IPFilterConfig
+	proxies *TrustedProxies
+	static  ipLists
+	lists   atomic.Pointer[ipLists]
+
+	mu      sync.Mutex // 保护文件的修改时间和大小，并串行化重新加载
+	modTime time.Time
+	size    int64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewIPFilter 创建 IP 访问控制，配置了 File 时启动后台协程检查文件修改
+func NewIPFilter(config *IPFilterConfig) (*IPFilter, error) {
+	if config == nil {
+		config = &IPFilterConfig{}
+	}
+
+	f := &IPFilter{config: *config, stop: make(chan struct{})}
+	var err error
+	if f.static.allow, err = parsePrefixes(config.Allow); err != nil {
+		return nil, err
+	}
+	if f.static.deny, err = parsePrefixes(config.Deny); err != nil {
+		return nil, err
+	}
+	if len(config.TrustedProxies) > 0 {
+		if f.proxies, err = NewTrustedProxies(config.TrustedProxies, config.ProxyHeaders...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	if config.File != "" {
+		interval := config.ReloadInterval
+		if interval <= 0 {
+			interval = 10 * time.Second
+		}
+		go f.watch(interval)
+	}
+	return f, nil
+}
+
+// Reload 重新加载访问控制列表文件，失败时保留当前生效的列表
+func (f *IPFilter) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lists := &ipLists{
+		allow: append([]netip.Prefix(nil), f.static.allow...),
+		deny:  append([]netip.Prefix(nil), f.static.deny...),
+	}
+
+	if f.config.File != "" {
+		info, err := os.Stat(f.config.File)
+		if err != nil {
+			return fmt.Errorf("ipfilter: %w", err)
+		}
+		// 加载失败时同样记录修改时间，文件再次修改后才会重试
+		f.modTime, f.size = info.ModTime(), info.Size()
+		allow, deny, err := loadIPListFile(f.config.File)
+		if err != nil {
+			return err
+		}
+		lists.allow = append(lists.allow, allow...)
+		lists.deny = append(lists.deny, deny...)
+	}
+
+	f.lists.Store(lists)
+	return nil
+}
+
+// watch 定期检查文件是否修改，修改后重新加载
+func (f *IPFilter) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !f.modified() {
+			continue
+		}
+		if err := f.Reload(); err != nil {
+			logger.WithError(err).WithField("file", f.config.File).Warn("IP 访问控制列表加载失败")
+			continue
+		}
+		logger.WithField("file", f.config.File).Info("IP 访问控制列表已更新")
+	}
+}
+
+// modified 判断文件是否在上次加载后被修改
+func (f *IPFilter) modified() bool {
+	info, err := os.Stat(f.config.File)
+	if err != nil {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !info.ModTime().Equal(f.modTime) || info.Size() != f.size
+}
+
+// Close 停止检查文件修改
+func (f *IPFilter) Close() {
+	f.closeOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+// Allowed 判断 IP 是否允许访问
+func (f *IPFilter) Allowed(ip string) bool {
+	addr, ok := parseAddr(ip)
+	if !ok {
+		return false
+	}
+
+	lists := f.lists.Load()
+	if containsAddr(lists.deny, addr) {
+		return false
+	}
+	return len(lists.allow) == 0 || containsAddr(lists.allow, addr)
+}
+
+// Middleware 返回 IP 访问控制中间件，不允许访问的请求返回 errors.CodeForbidden
+func (f *IPFilter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := ClientIP(c)
+		if f.proxies != nil {
+			ip = f.proxies.ClientIP(c.Request)
+			c.Set(ClientIPKey, ip)
+		}
+
+		if !f.Allowed(ip) {
+			logger.Ctx(c).WithField("client_ip", ip).Debug("IP 访问被拒绝")
+			abortWithError(c, errors.New(errors.CodeForbidden, "access denied"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// loadIPListFile 读取访问控制列表文件
+func loadIPListFile(path string) (allow, deny []netip.Prefix, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ipfilter: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		if text == "" {
+			continue
+		}
+
+		action, value := "allow", text
+		if fields := strings.Fields(text); len(fields) == 2 {
+			action, value = strings.ToLower(fields[0]), fields[1]
+		}
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ipfilter: %s:%d: %w", path, line, err)
+		}
+		switch action {
+		case "allow":
+			allow = append(allow, prefix)
+		case "deny":
+			deny = append(deny, prefix)
+		default:
+			return nil, nil, fmt.Errorf("ipfilter: %s:%d: unknown action %q", path, line, action)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("ipfilter: %w", err)
+	}
+	return allow, deny, nil
+}
+
+// parsePrefixes 解析 IP 或网段列表
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		p, err := parsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("ipfilter: %w", err)
+		}
+		prefixes = append(prefixes, p)
+	}
+	return prefixes, nil
+}
+
+// parsePrefix 解析 IP 或网段，单个 IP 视为 /32 或 /128
+func parsePrefix(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		p, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if p.Addr().Is4In6() && p.Bits() >= 96 {
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		return p.Masked(), nil
+	}
+
+	addr, ok := parseAddr(value)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", value)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// parseAddr 解析 IP 地址，IPv4 映射的 IPv6 地址转换为 IPv4，忽略 IPv6 区域
+func parseAddr(value string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// containsAddr 判断地址是否属于任一网段
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP 获取直连地址，不解析任何代理请求头
+func peerIP(r *http.Request) string {
+	host := remoteHost(r.RemoteAddr)
+	if addr, ok := parseAddr(host); ok {
+		return addr.String()
+	}
+	return host
+}
+
+// remoteHost 获取直连地址中的主机部分
+func remoteHost(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(strings.TrimSpace(remoteAddr)); err == nil {
+		return host
+	}
+	return strings.TrimSpace(remoteAddr)
+}
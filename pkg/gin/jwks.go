@@ -0,0 +1,285 @@
+package gin
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+)
+
+// jwksMinRefreshInterval 遇到未知 kid 时触发刷新的最小间隔，避免伪造的 kid 导致频繁请求 JWKS
+const jwksMinRefreshInterval = time.Minute
+
+// jsonWebKey JWKS 中的单个密钥
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+// jwksKey JWKS 中解析出的验签密钥
+type jwksKey struct {
+	key interface{}
+	alg string // JWKS 中声明的算法，不为空时只能用于该算法
+}
+
+// jwksCache 缓存从文件或 URL 加载的 JWKS，过期后在使用时刷新，刷新失败时继续使用旧的密钥
+type jwksCache struct {
+	file     string
+	url      string
+	client   *http.Client
+	interval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]jwksKey
+	fetched   time.Time // 最近一次刷新成功的时间
+	attempted time.Time // 最近一次尝试刷新的时间
+
+	refreshMu  sync.Mutex  // 串行化刷新
+	refreshing atomic.Bool // 是否有后台刷新正在进行
+}
+
+// newJWKSCache 创建 JWKS 缓存并加载密钥
+func newJWKSCache(ctx context.Context, file, url string, client *http.Client, interval time.Duration) (*jwksCache, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	s := &jwksCache{file: file, url: url, client: client, interval: interval}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// key 获取 kid 对应的密钥
+// kid 为空时，JWKS 中只有一个密钥则使用该密钥；
+// 缓存过期时继续使用缓存的密钥并在后台刷新，kid 不存在时同步刷新后重试
+func (s *jwksCache) key(ctx context.Context, kid string) (jwksKey, bool) {
+	s.mu.RLock()
+	key, ok := s.lookup(kid)
+	due := s.due(ok)
+	s.mu.RUnlock()
+	if !due {
+		return key, ok
+	}
+	if ok {
+		if s.refreshing.CompareAndSwap(false, true) {
+			go s.backgroundRefresh(context.WithoutCancel(ctx))
+		}
+		return key, true
+	}
+
+	s.refreshMu.Lock()
+	s.mu.RLock()
+	due = s.due(ok)
+	s.mu.RUnlock()
+	if due {
+		if err := s.refresh(ctx); err != nil {
+			logger.Ctx(ctx).WithError(err).Warn("JWKS 刷新失败")
+		}
+	}
+	s.refreshMu.Unlock()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lookup(kid)
+}
+
+// backgroundRefresh 后台刷新过期的缓存
+func (s *jwksCache) backgroundRefresh(ctx context.Context) {
+	defer s.refreshing.Store(false)
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	s.mu.RLock()
+	due := s.due(true)
+	s.mu.RUnlock()
+	if !due {
+		return
+	}
+	if err := s.refresh(ctx); err != nil {
+		logger.Ctx(ctx).WithError(err).Warn("JWKS 刷新失败")
+	}
+}
+
+// due 判断是否需要刷新，两次尝试至少间隔 jwksMinRefreshInterval，调用方需持有读锁
+func (s *jwksCache) due(found bool) bool {
+	if time.Since(s.attempted) < jwksMinRefreshInterval {
+		return false
+	}
+	return !found || time.Since(s.fetched) > s.interval
+}
+
+// lookup 查找密钥，调用方需持有读锁
+func (s *jwksCache) lookup(kid string) (jwksKey, bool) {
+	if kid == "" {
+		if len(s.keys) != 1 {
+			return jwksKey{}, false
+		}
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh 重新加载 JWKS
+func (s *jwksCache) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.attempted = time.Now()
+	s.mu.Unlock()
+
+	data, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetched = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// fetch 读取 JWKS 内容
+func (s *jwksCache) fetch(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status %d from %s", resp.StatusCode, s.url)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	return data, nil
+}
+
+// parseJWKS 解析 JWKS，忽略不支持的密钥类型、曲线和非签名用途的密钥，格式错误的密钥记录日志后跳过
+func parseJWKS(data []byte) (map[string]jwksKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.WithError(err).WithField("kid", jwk.Kid).Warn("JWKS 密钥格式错误，已跳过")
+			continue
+		}
+		if key != nil {
+			keys[jwk.Kid] = jwksKey{key: key, alg: jwk.Alg}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks: no usable signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey 转换为验签使用的密钥，不支持的密钥类型或曲线返回 nil
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := key.ECDH(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	case "oct":
+		return decodeBase64URL(k.K)
+	default:
+		return nil, nil
+	}
+}
+
+// decodeBase64URL 解码不带填充的 base64url
+func decodeBase64URL(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing key parameter")
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
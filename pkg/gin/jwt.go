@@ -0,0 +1,466 @@
+package gin
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsKey gin.Context 中保存 JWT 声明的键
+const ClaimsKey = "calorie.jwt.claims"
+
+// JWTConfig JWT 认证配置
+type JWTConfig struct {
+	// 允许的签名算法，支持 HS256、RS256、ES256，默认为已配置密钥对应的算法
+	Algorithms []string
+	// HS256 密钥
+	Secret []byte
+	// RS256/ES256 公钥，*rsa.PublicKey 或 *ecdsa.PublicKey
+	PublicKey interface{}
+	// PEM 编码的 RS256/ES256 公钥，PublicKey 为空时使用
+	PublicKeyPEM []byte
+	// 按 kid 指定的静态密钥，HS256 为 []byte，RS256/ES256 为公钥
+	Keys map[string]interface{}
+	// JWKS 文件路径或 URL，二选一，按 kid 选择密钥
+	JWKSFile string
+	JWKSURL  string
+	// JWKS 刷新间隔，默认 1 小时，过期后在后台刷新；遇到未知 kid 时同步刷新，两次刷新至少间隔 1 分钟
+	JWKSRefreshInterval time.Duration
+	// 请求 JWKS 的 HTTP 客户端，默认超时 10 秒
+	HTTPClient *http.Client
+
+	// 签发者，不为空时校验 iss
+	Issuer string
+	// 接收方，不为空时 aud 需包含其中之一
+	Audience []string
+	// 校验 exp/nbf/iat 时允许的时钟偏差
+	Leeway time.Duration
+	// 是否允许没有 exp 的令牌
+	AllowMissingExpiration bool
+
+	// 令牌来源，按顺序查找，格式为 "header:<名称>"、"query:<名称>" 或 "cookie:<名称>"，
+	// 多个来源用逗号分隔，默认 "header:Authorization"
+	TokenLookup string
+	// 请求头中令牌的前缀，默认 "Bearer"
+	AuthScheme string
+	// 角色和权限范围所在的声明名称，默认 "roles" 和 "scope"
+	// 声明值可以是字符串数组，也可以是空格分隔的字符串
+	RolesClaim  string
+	ScopesClaim string
+	// 没有令牌时是否放行，令牌无效时仍然返回 401
+	Optional bool
+}
+
+// Claims JWT 声明
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ID        string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	Roles     []string
+	Scopes    []string
+	// 全部声明
+	Raw jwt.MapClaims
+}
+
+// Get 获取声明
+func (c *Claims) Get(key string) (interface{}, bool) {
+	v, ok := c.Raw[key]
+	return v, ok
+}
+
+// String 获取字符串声明，不存在或类型不符时返回空字符串
+func (c *Claims) String(key string) string {
+	s, _ := c.Raw[key].(string)
+	return s
+}
+
+// HasRole 判断是否拥有角色
+func (c *Claims) HasRole(role string) bool {
+	return containsString(c.Roles, role)
+}
+
+// HasScope 判断是否拥有权限范围
+func (c *Claims) HasScope(scope string) bool {
+	return containsString(c.Scopes, scope)
+}
+
+// tokenSource 令牌来源
+type tokenSource struct {
+	kind string
+	name string
+}
+
+// JWTAuth JWT 认证
+type JWTAuth struct {
+	config  JWTConfig
+	parser  *jwt.Parser
+	sources []tokenSource
+	jwks    *jwksCache
+}
+
+// NewJWTAuth 创建 JWT 认证，配置了 JWKS 时会立即加载一次，加载失败返回错误
+func NewJWTAuth(config *JWTConfig) (*JWTAuth, error) {
+	if config == nil {
+		return nil, fmt.Errorf("jwt: config is nil")
+	}
+
+	cfg := *config
+	if cfg.PublicKey == nil && len(cfg.PublicKeyPEM) > 0 {
+		key, err := parsePublicKeyPEM(cfg.PublicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		cfg.PublicKey = key
+	}
+	if cfg.JWKSFile != "" && cfg.JWKSURL != "" {
+		return nil, fmt.Errorf("jwt: JWKSFile and JWKSURL are mutually exclusive")
+	}
+	hasKeys := len(cfg.Secret) > 0 || cfg.PublicKey != nil || len(cfg.Keys) > 0 || cfg.JWKSFile != "" || cfg.JWKSURL != ""
+	if !hasKeys {
+		return nil, fmt.Errorf("jwt: no verification key configured")
+	}
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = defaultAlgorithms(&cfg)
+	}
+	for _, alg := range cfg.Algorithms {
+		if alg != "HS256" && alg != "RS256" && alg != "ES256" {
+			return nil, fmt.Errorf("jwt: unsupported algorithm %q", alg)
+		}
+	}
+	if cfg.TokenLookup == "" {
+		cfg.TokenLookup = "header:Authorization"
+	}
+	if cfg.AuthScheme == "" {
+		cfg.AuthScheme = "Bearer"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.ScopesClaim == "" {
+		cfg.ScopesClaim = "scope"
+	}
+
+	a := &JWTAuth{config: cfg}
+	for _, item := range strings.Split(cfg.TokenLookup, ",") {
+		kind, name, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || name == "" || (kind != "header" && kind != "query" && kind != "cookie") {
+			return nil, fmt.Errorf("jwt: invalid token lookup %q", item)
+		}
+		a.sources = append(a.sources, tokenSource{kind: kind, name: name})
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(cfg.Algorithms), jwt.WithLeeway(cfg.Leeway), jwt.WithIssuedAt()}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if !cfg.AllowMissingExpiration {
+		options = append(options, jwt.WithExpirationRequired())
+	}
+	a.parser = jwt.NewParser(options...)
+
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		jwks, err := newJWKSCache(context.Background(), cfg.JWKSFile, cfg.JWKSURL, cfg.HTTPClient, cfg.JWKSRefreshInterval)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+	}
+	return a, nil
+}
+
+// defaultAlgorithms 根据已配置的密钥确定允许的算法
+func defaultAlgorithms(cfg *JWTConfig) []string {
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" || len(cfg.Keys) > 0 {
+		return []string{"HS256", "RS256", "ES256"}
+	}
+
+	var algs []string
+	if len(cfg.Secret) > 0 {
+		algs = append(algs, "HS256")
+	}
+	switch cfg.PublicKey.(type) {
+	case *rsa.PublicKey:
+		algs = append(algs, "RS256")
+	case *ecdsa.PublicKey:
+		algs = append(algs, "ES256")
+	}
+	return algs
+}
+
+// parsePublicKeyPEM 解析 PEM 编码的 RSA 或 ECDSA 公钥
+func parsePublicKeyPEM(data []byte) (interface{}, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("jwt: invalid public key PEM")
+}
+
+// Parse 校验令牌并返回声明
+func (a *JWTAuth) Parse(ctx context.Context, tokenString string) (*Claims, error) {
+	token, err := a.parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return a.key(ctx, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("jwt: unexpected claims type %T", token.Claims)
+	}
+	claims := newClaims(raw, a.config.RolesClaim, a.config.ScopesClaim)
+	if len(a.config.Audience) > 0 && !containsAny(claims.Audience, a.config.Audience) {
+		return nil, jwt.ErrTokenInvalidAudience
+	}
+	return claims, nil
+}
+
+// key 根据算法和 kid 选择验签密钥
+// HS256 只使用对称密钥，RS256/ES256 只使用公钥，避免算法混淆攻击
+func (a *JWTAuth) key(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	symmetric := token.Method.Alg() == "HS256"
+
+	var key interface{}
+	if k, ok := a.config.Keys[kid]; ok && kid != "" {
+		key = k
+	} else if a.jwks != nil {
+		if k, ok := a.jwks.key(ctx, kid); ok {
+			// JWKS 声明了算法时，令牌的 alg 必须与之一致
+			if k.alg != "" && k.alg != token.Method.Alg() {
+				return nil, fmt.Errorf("key %q is for %s, not %s", kid, k.alg, token.Method.Alg())
+			}
+			key = k.key
+		}
+	}
+	if key == nil {
+		if symmetric && len(a.config.Secret) > 0 {
+			key = a.config.Secret
+		} else if !symmetric {
+			key = a.config.PublicKey
+		}
+	}
+
+	switch k := key.(type) {
+	case []byte:
+		if symmetric {
+			return k, nil
+		}
+	case *rsa.PublicKey:
+		if token.Method.Alg() == "RS256" {
+			return k, nil
+		}
+	case *ecdsa.PublicKey:
+		// ES256 只能使用 P-256 曲线
+		if token.Method.Alg() == "ES256" && k.Curve == elliptic.P256() {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s key for kid %q", token.Method.Alg(), kid)
+}
+
+// token 按配置的来源查找令牌
+func (a *JWTAuth) token(c *gin.Context) string {
+	for _, src := range a.sources {
+		switch src.kind {
+		case "header":
+			value := c.GetHeader(src.name)
+			if value == "" {
+				continue
+			}
+			if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, a.config.AuthScheme) {
+				return strings.TrimSpace(token)
+			}
+		case "query":
+			if value := c.Query(src.name); value != "" {
+				return value
+			}
+		case "cookie":
+			if value, err := c.Cookie(src.name); err == nil && value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// Middleware 返回 JWT 认证中间件
+// 认证通过后声明保存在 gin.Context 中，通过 ClaimsFrom 获取，sub 同时作为 user_id 写入日志字段；
+// 认证失败时通过统一响应返回 errors.CodeUnauthorized
+func (a *JWTAuth) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := a.token(c)
+		if tokenString == "" {
+			if a.config.Optional {
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", a.config.AuthScheme)
+			abortWithError(c, errors.New(errors.CodeUnauthorized, "missing token"))
+			return
+		}
+
+		claims, err := a.Parse(c.Request.Context(), tokenString)
+		if err != nil {
+			message := "invalid token"
+			if stderrors.Is(err, jwt.ErrTokenExpired) {
+				message = "token expired"
+			}
+			logger.Ctx(c).WithError(err).Debug("JWT 认证失败")
+			c.Header("WWW-Authenticate", fmt.Sprintf(`%s error="invalid_token"`, a.config.AuthScheme))
+			abortWithError(c, errors.Wrap(err, errors.CodeUnauthorized, message))
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		if claims.Subject != "" {
+			c.Set(logger.FieldUserID, claims.Subject)
+			AddLogFields(c, logger.Fields{logger.FieldUserID: claims.Subject})
+		}
+		c.Next()
+	}
+}
+
+// ClaimsFrom 获取 JWT 认证中间件保存的声明
+func ClaimsFrom(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
+
+// SubjectFrom 获取 JWT 的 sub 声明，未认证时返回空字符串
+func SubjectFrom(c *gin.Context) string {
+	if claims, ok := ClaimsFrom(c); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// RequireRoles 要求拥有任一角色，未认证返回 errors.CodeUnauthorized，没有角色返回 errors.CodeForbidden
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFrom(c)
+		if !ok {
+			abortWithError(c, errors.New(errors.CodeUnauthorized, "missing token"))
+			return
+		}
+		if !containsAny(claims.Roles, roles) {
+			abortWithError(c, errors.New(errors.CodeForbidden, "insufficient role"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScopes 要求拥有全部权限范围，JWT 和 API Key 认证均可使用
+// 未认证返回 errors.CodeUnauthorized，缺少权限范围返回 errors.CodeForbidden
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var hasScope func(string) bool
+		if claims, ok := ClaimsFrom(c); ok {
+			hasScope = claims.HasScope
+		} else if key, ok := APIKeyFrom(c); ok {
+			hasScope = key.HasScope
+		} else {
+			abortWithError(c, errors.New(errors.CodeUnauthorized, "missing credentials"))
+			return
+		}
+		for _, scope := range scopes {
+			if !hasScope(scope) {
+				abortWithError(c, errors.New(errors.CodeForbidden, "insufficient scope").WithParam("scope", scope))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// abortWithError 通过统一响应返回错误并中断请求，错误同时记录到 c.Errors
+func abortWithError(c *gin.Context, e *errors.Error) {
+	_ = c.Error(e)
+	HandleError(c, e)
+	c.Abort()
+}
+
+// newClaims 从全部声明中提取常用字段
+func newClaims(raw jwt.MapClaims, rolesClaim, scopesClaim string) *Claims {
+	claims := &Claims{
+		Raw:    raw,
+		Roles:  stringList(raw[rolesClaim]),
+		Scopes: stringList(raw[scopesClaim]),
+	}
+	claims.Subject, _ = raw.GetSubject()
+	claims.Issuer, _ = raw.GetIssuer()
+	claims.Audience, _ = raw.GetAudience()
+	claims.ID, _ = raw["jti"].(string)
+	if t, _ := raw.GetExpirationTime(); t != nil {
+		claims.ExpiresAt = t.Time
+	}
+	if t, _ := raw.GetNotBefore(); t != nil {
+		claims.NotBefore = t.Time
+	}
+	if t, _ := raw.GetIssuedAt(); t != nil {
+		claims.IssuedAt = t.Time
+	}
+	return claims
+}
+
+// stringList 将字符串数组或空格分隔的字符串转换为字符串列表
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// containsString 判断列表是否包含字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny 判断列表是否包含任一字符串
+func containsAny(list, targets []string) bool {
+	for _, t := range targets {
+		if containsString(list, t) {
+			return true
+		}
+	}
+	return false
+}
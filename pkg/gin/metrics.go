@@ -287,7 +287,7 @@ func PrometheusMiddleware(client *metrics.Client, config *MetricsConfig) gin.Han
 
 		// 记录速率限制
 		if config.EnableRateLimit && rateLimitCounter != nil {
-			if isRateLimited, exists := c.Get("rate_limited"); exists {
+			if isRateLimited, exists := c.Get(rateLimitedKey); exists {
 				if isLimited, ok := isRateLimited.(bool); ok && isLimited {
 					rateLimitCounter.WithLabelValues(
 						c.Request.Method,
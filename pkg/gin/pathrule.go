@@ -0,0 +1,235 @@
+package gin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// 路径匹配方式
+const (
+	MatchExact  = "exact"  // 完全相等
+	MatchPrefix = "prefix" // 前缀匹配
+	MatchGlob   = "glob"   // 通配符匹配，* 匹配任意字符（包括 /），? 匹配除 / 外的单个字符
+	MatchRegex  = "regex"  // 正则匹配，需要完整匹配时请自行添加 ^ 和 $
+)
+
+// PathRule 路径访问规则
+type PathRule struct {
+	// 请求方法，为空时匹配所有方法
+	Method string
+	// 匹配模式
+	Pattern string
+	// 匹配方式，默认 MatchExact
+	Match string
+	// 是否匹配 gin 路由模板（c.FullPath()，如 /users/:id），默认匹配请求路径
+	Route bool
+	// 匹配后是否拒绝访问
+	Deny bool
+}
+
+// String 返回规则描述，用于匹配追踪日志
+func (r PathRule) String() string {
+	var b strings.Builder
+	if r.Deny {
+		b.WriteString("deny ")
+	} else {
+		b.WriteString("allow ")
+	}
+	if r.Method != "" {
+		b.WriteString(r.Method)
+		b.WriteByte(' ')
+	}
+	if r.Route {
+		b.WriteString("route ")
+	}
+	match := r.Match
+	if match == "" {
+		match = MatchExact
+	}
+	b.WriteString(match)
+	b.WriteByte(':')
+	b.WriteString(r.Pattern)
+	return b.String()
+}
+
+// ParsePathRule 解析字符串形式的路径规则，格式为 [METHOD ]<kind>:<pattern> 或 [METHOD ]<pattern>
+// kind 可选 exact、prefix、glob、regex 和 route（完全匹配路由模板）；
+// 省略 kind 时，包含 * 或 ? 的模式按 glob 匹配，否则按 exact 匹配
+//
+//	/health             完全匹配
+//	/admin/*            匹配 /admin/ 下的所有路径
+//	GET prefix:/api/v1/ 匹配 GET 请求的 /api/v1/ 前缀
+//	regex:^/v[0-9]+/    正则匹配
+//	route:/users/:id    匹配路由模板
+func ParsePathRule(s string, deny bool) (PathRule, error) {
+	rule := PathRule{Deny: deny}
+	s = strings.TrimSpace(s)
+	if method, rest, ok := strings.Cut(s, " "); ok && method != "" && !strings.ContainsAny(method, "/:") {
+		rule.Method = strings.ToUpper(method)
+		s = strings.TrimSpace(rest)
+	}
+
+	kind, pattern, ok := strings.Cut(s, ":")
+	switch {
+	case ok && (kind == MatchExact || kind == MatchPrefix || kind == MatchGlob || kind == MatchRegex):
+		rule.Match, rule.Pattern = kind, pattern
+	case ok && kind == "route":
+		rule.Match, rule.Pattern, rule.Route = MatchExact, pattern, true
+	case strings.ContainsAny(s, "*?"):
+		rule.Match, rule.Pattern = MatchGlob, s
+	default:
+		rule.Match, rule.Pattern = MatchExact, s
+	}
+
+	if rule.Pattern == "" {
+		return rule, fmt.Errorf("path rule %q: empty pattern", s)
+	}
+	return rule, nil
+}
+
+// compiledPathRule 预编译的路径规则
+type compiledPathRule struct {
+	PathRule
+	re *regexp.Regexp
+}
+
+// matches 判断请求是否匹配规则
+func (r *compiledPathRule) matches(method, path, route string) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+
+	target := path
+	if r.Route {
+		if route == "" {
+			return false
+		}
+		target = route
+	}
+
+	switch r.Match {
+	case MatchPrefix:
+		return strings.HasPrefix(target, r.Pattern)
+	case MatchGlob, MatchRegex:
+		return r.re.MatchString(target)
+	default:
+		return target == r.Pattern
+	}
+}
+
+// pathMatcher 路径访问控制，按顺序匹配，第一条匹配的规则生效
+// 没有规则匹配时，存在允许规则则拒绝访问，否则放行
+type pathMatcher struct {
+	rules    []compiledPathRule
+	hasAllow bool
+	trace    bool
+}
+
+// newPathMatcher 根据配置创建路径访问控制
+// 规则顺序为 PathBlacklist、PathRules、PathWhitelist
+func newPathMatcher(config *RequestConfig) (*pathMatcher, error) {
+	var rules []PathRule
+	for _, s := range config.PathBlacklist {
+		r, err := ParsePathRule(s, true)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	rules = append(rules, config.PathRules...)
+	for _, s := range config.PathWhitelist {
+		r, err := ParsePathRule(s, false)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	m := &pathMatcher{rules: make([]compiledPathRule, 0, len(rules)), trace: config.PathTrace}
+	for _, r := range rules {
+		compiled, err := compilePathRule(r)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, compiled)
+		if !r.Deny {
+			m.hasAllow = true
+		}
+	}
+	return m, nil
+}
+
+// compilePathRule 预编译规则中的正则和通配符
+func compilePathRule(r PathRule) (compiledPathRule, error) {
+	compiled := compiledPathRule{PathRule: r}
+	switch r.Match {
+	case "", MatchExact, MatchPrefix:
+	case MatchGlob:
+		compiled.re = globRegexp(r.Pattern)
+	case MatchRegex:
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return compiled, fmt.Errorf("path rule %s: %w", r, err)
+		}
+		compiled.re = re
+	default:
+		return compiled, fmt.Errorf("path rule %s: unknown match %q", r, r.Match)
+	}
+	return compiled, nil
+}
+
+// globRegexp 将通配符模式转换为完整匹配的正则
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteByte('^')
+	for _, ch := range pattern {
+		switch ch {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteByte('$')
+	return regexp.MustCompile(b.String())
+}
+
+// allow 检查请求路径是否允许访问，开启追踪时输出匹配结果
+func (m *pathMatcher) allow(c *gin.Context) bool {
+	if len(m.rules) == 0 {
+		return true
+	}
+
+	method, path, route := c.Request.Method, c.Request.URL.Path, c.FullPath()
+	for i := range m.rules {
+		r := &m.rules[i]
+		if r.matches(method, path, route) {
+			m.traceMatch(c, route, r.String(), !r.Deny)
+			return !r.Deny
+		}
+	}
+
+	allowed := !m.hasAllow
+	m.traceMatch(c, route, "default", allowed)
+	return allowed
+}
+
+// traceMatch 输出路径规则的匹配结果
+func (m *pathMatcher) traceMatch(c *gin.Context, route, rule string, allowed bool) {
+	if !m.trace {
+		return
+	}
+	logger.Ctx(c).WithFields(logger.Fields{
+		"method":  c.Request.Method,
+		"path":    c.Request.URL.Path,
+		"route":   route,
+		"rule":    rule,
+		"allowed": allowed,
+	}).Info("路径规则匹配")
+}
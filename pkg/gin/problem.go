@@ -0,0 +1,62 @@
+package gin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType RFC 7807 响应类型
+const problemContentType = "application/problem+json"
+
+// Problem RFC 7807 问题详情结构
+type Problem struct {
+	Type     string `json:"type"`               // 问题类型 URI
+	Title    string `json:"title"`              // 问题类型摘要
+	Status   int    `json:"status"`             // HTTP 状态码
+	Detail   string `json:"detail,omitempty"`   // 本次问题的具体说明
+	Instance string `json:"instance,omitempty"` // 发生问题的请求路径
+
+	// 扩展字段
+	Code      errors.ErrorCode    `json:"code"`                 // 错误码
+	RequestID string              `json:"request_id,omitempty"` // 请求ID
+	Timestamp int64               `json:"timestamp"`            // 时间戳
+	Details   []errors.FieldError `json:"details,omitempty"`    // 字段校验错误
+	Stack     string              `json:"stack,omitempty"`      // 调用栈，仅调试模式下返回
+}
+
+// newProblem 根据错误创建问题详情
+func newProblem(c *gin.Context, cfg *Config, e *errors.Error, status int, err error) *Problem {
+	p := &Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    localizedMessage(c, cfg, e),
+		Instance:  c.Request.URL.Path,
+		Code:      e.Code,
+		Timestamp: time.Now().Unix(),
+	}
+
+	// 使用自定义 type 时，title 描述该错误码而不是 HTTP 状态
+	if cfg.ProblemTypeBase != "" {
+		p.Type = strings.TrimSuffix(cfg.ProblemTypeBase, "/") + "/" + strconv.Itoa(int(e.Code))
+		p.Title = errors.GetMessage(e.Code)
+	}
+
+	if cfg.EnableRequestID {
+		p.RequestID = requestID(c, cfg)
+	}
+	if err != nil {
+		if v, ok := ValidationErrorFrom(err); ok {
+			p.Details = v.Fields
+		}
+		if cfg.Debug {
+			p.Stack = errors.Stack(err)
+		}
+	}
+	return p
+}
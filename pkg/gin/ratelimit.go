@@ -0,0 +1,275 @@
+package gin
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// 限流相关的响应头
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"     // 限流窗口内允许的请求数
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // 剩余可用请求数
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // 配额完全恢复所需的秒数
+	HeaderRetryAfter         = "Retry-After"           // 被限流时建议的重试等待秒数
+)
+
+// rateLimitedKey gin.Context 中标记请求被限流的键，PrometheusMiddleware 据此记录限流次数
+const rateLimitedKey = "rate_limited"
+
+// 按键限流器缓存的默认值
+const (
+	defaultRateLimitCacheSize = 10000
+	defaultRateLimitTTL       = 10 * time.Minute
+)
+
+// KeyFunc 限流键提取函数
+// 返回空字符串时使用客户端 IP 作为限流键
+type KeyFunc func(c *gin.Context) string
+
+// KeyByClientIP 按客户端 IP 限流
+func KeyByClientIP() KeyFunc {
+	return func(c *gin.Context) string {
+		return "ip:" + ClientIP(c)
+	}
+}
+
+// KeyByHeader 按请求头的值限流，如 KeyByHeader("X-API-Key") 按 API Key 限流
+// 请求头的值可能是密钥，使用其 SHA-256 哈希值作为限流键，避免明文出现在 Redis 键名中
+func KeyByHeader(name string) KeyFunc {
+	return func(c *gin.Context) string {
+		if v := c.GetHeader(name); v != "" {
+			return "header:" + hashKey(v)
+		}
+		return ""
+	}
+}
+
+// hashKey 计算限流键的 SHA-256 十六进制哈希值
+func hashKey(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyByUserID 按用户ID限流
+// 用户ID 优先取 gin.Context 中的 user_id，其次取请求上下文中 logger.ContextWithUserID 写入的值
+func KeyByUserID() KeyFunc {
+	return func(c *gin.Context) string {
+		if v, ok := c.Get(logger.FieldUserID); ok && v != nil {
+			return fmt.Sprintf("user:%v", v)
+		}
+		if v, ok := logger.FieldsFromContext(c.Request.Context())[logger.FieldUserID]; ok && v != nil {
+			return fmt.Sprintf("user:%v", v)
+		}
+		return ""
+	}
+}
+
+// RouteRateLimit 按路由覆盖的限流规则
+type RouteRateLimit struct {
+	// 请求方法，为空时匹配所有方法
+	Method string
+	// gin 路由模板，如 /users/:id；未注册的路由按请求路径匹配
+	Path string
+	// 每秒请求数，小于等于 0 时该路由不限流
+	Rate int
+	// 突发请求数，小于等于 0 时与 Rate 相同
+	Burst int
+	// 限流键提取函数，为空时使用 RequestConfig.RateLimitKey
+	Key KeyFunc
+}
+
+// rateLimitRule 生效的限流规则
+type rateLimitRule struct {
+	name  string
+	limit rate.Limit
+	burst int
+	key   KeyFunc
+}
+
+// requestRateLimiter RequestMiddleware 使用的进程内限流器
+type requestRateLimiter struct {
+	global *rateLimitRule
+	routes map[string]*rateLimitRule // 键为 "METHOD path"，Method 为空时为 " path"
+	cache  *limiterCache
+}
+
+// newRequestRateLimiter 根据配置创建限流器
+func newRequestRateLimiter(config *RequestConfig) *requestRateLimiter {
+	size := config.RateLimitCacheSize
+	if size <= 0 {
+		size = defaultRateLimitCacheSize
+	}
+	ttl := config.RateLimitTTL
+	if ttl <= 0 {
+		ttl = defaultRateLimitTTL
+	}
+
+	l := &requestRateLimiter{
+		global: newRateLimitRule("global", config.RateLimit, config.RateBurst, config.RateLimitKey),
+		routes: make(map[string]*rateLimitRule, len(config.RateLimitRoutes)),
+		cache:  newLimiterCache(size, ttl),
+	}
+	for _, r := range config.RateLimitRoutes {
+		key := r.Key
+		if key == nil {
+			key = config.RateLimitKey
+		}
+		name := r.Method + " " + r.Path
+		l.routes[name] = newRateLimitRule(name, r.Rate, r.Burst, key)
+	}
+	return l
+}
+
+// newRateLimitRule 创建限流规则，rps 小于等于 0 时返回 nil 表示不限流
+func newRateLimitRule(name string, rps, burst int, key KeyFunc) *rateLimitRule {
+	if rps <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = rps
+	}
+	return &rateLimitRule{name: name, limit: rate.Limit(rps), burst: burst, key: key}
+}
+
+// rule 获取请求适用的限流规则，路由规则优先于全局规则
+func (l *requestRateLimiter) rule(c *gin.Context) *rateLimitRule {
+	if len(l.routes) > 0 {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		if r, ok := l.routes[c.Request.Method+" "+path]; ok {
+			return r
+		}
+		if r, ok := l.routes[" "+path]; ok {
+			return r
+		}
+	}
+	return l.global
+}
+
+// allow 检查请求是否超过限流，写入限流响应头，超过时中断请求并返回 429
+func (l *requestRateLimiter) allow(c *gin.Context) bool {
+	rule := l.rule(c)
+	if rule == nil {
+		return true
+	}
+
+	key := ""
+	if rule.key != nil {
+		if key = rule.key(c); key == "" {
+			key = "ip:" + ClientIP(c)
+		}
+	}
+
+	now := time.Now()
+	limiter := l.cache.get(rule.name+"|"+key, now, func() *rate.Limiter {
+		return rate.NewLimiter(rule.limit, rule.burst)
+	})
+	allowed := limiter.AllowN(now, 1)
+
+	tokens := limiter.TokensAt(now)
+	remaining := int(math.Max(0, math.Floor(tokens)))
+	reset := time.Duration((float64(rule.burst) - tokens) / float64(rule.limit) * float64(time.Second))
+	setRateLimitHeaders(c, rule.burst, remaining, reset)
+
+	if !allowed {
+		retryAfter := time.Duration((1 - tokens) / float64(rule.limit) * float64(time.Second))
+		c.Header(HeaderRetryAfter, strconv.Itoa(ceilSeconds(retryAfter)))
+		c.Set(rateLimitedKey, true)
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"code":    http.StatusTooManyRequests,
+			"message": "Too Many Requests",
+		})
+		return false
+	}
+	return true
+}
+
+// setRateLimitHeaders 写入 X-RateLimit-* 响应头
+func setRateLimitHeaders(c *gin.Context, limit, remaining int, reset time.Duration) {
+	c.Header(HeaderRateLimitLimit, strconv.Itoa(limit))
+	c.Header(HeaderRateLimitRemaining, strconv.Itoa(remaining))
+	c.Header(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(reset)))
+}
+
+// ceilSeconds 向上取整的秒数，小于等于 0 时返回 0
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// limiterCache 按键保存限流器的 LRU 缓存
+// 超过容量时淘汰最久未使用的限流器，空闲超过 ttl 的限流器会被重新创建
+// ttl 应不小于令牌桶完全恢复所需的时间（Burst/Rate），否则客户端可以通过短暂空闲提前重置配额
+type limiterCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	ll       *list.List // 按最近使用排序，最近使用的在前
+	items    map[string]*list.Element
+}
+
+// limiterCacheEntry 缓存的限流器
+type limiterCacheEntry struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newLimiterCache 创建限流器缓存
+func newLimiterCache(capacity int, ttl time.Duration) *limiterCache {
+	return &limiterCache{
+		capacity: capacity,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get 获取键对应的限流器，不存在或已过期时调用 create 创建
+func (c *limiterCache) get(key string, now time.Time, create func() *rate.Limiter) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*limiterCacheEntry)
+		if now.Sub(entry.lastSeen) <= c.ttl {
+			entry.lastSeen = now
+			c.ll.MoveToFront(el)
+			return entry.limiter
+		}
+		c.remove(el)
+	}
+
+	// 清理末尾已过期的限流器，再按容量淘汰
+	for el := c.ll.Back(); el != nil; el = c.ll.Back() {
+		if now.Sub(el.Value.(*limiterCacheEntry).lastSeen) <= c.ttl && c.ll.Len() < c.capacity {
+			break
+		}
+		c.remove(el)
+	}
+
+	entry := &limiterCacheEntry{key: key, limiter: create(), lastSeen: now}
+	c.items[key] = c.ll.PushFront(entry)
+	return entry.limiter
+}
+
+// remove 删除缓存项，调用方需持有锁
+func (c *limiterCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*limiterCacheEntry).key)
+}
@@ -0,0 +1,83 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/NHYCRaymond/calorie/pkg/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// RedisRateLimitConfig 分布式限流中间件配置
+type RedisRateLimitConfig struct {
+	// 限流键提取函数，默认按客户端 IP 限流
+	Key KeyFunc
+	// Redis 不可用时是否放行请求，为 false 时返回 503
+	FailOpen bool
+	// 单次限流检查的超时时间，默认 100 毫秒
+	Timeout time.Duration
+}
+
+// DefaultRedisRateLimitConfig 默认配置
+var DefaultRedisRateLimitConfig = &RedisRateLimitConfig{
+	Key:      KeyByClientIP(),
+	FailOpen: true,
+	Timeout:  100 * time.Millisecond,
+}
+
+// RedisRateLimitMiddleware 基于 Redis 的分布式限流中间件，多个服务实例共享同一份配额
+// 被限流时返回 429 和 Retry-After，所有响应都会带有 X-RateLimit-* 响应头
+func RedisRateLimitMiddleware(limiter *redis.RateLimiter, config ...*RedisRateLimitConfig) gin.HandlerFunc {
+	cfg := DefaultRedisRateLimitConfig
+	if len(config) > 0 && config[0] != nil {
+		cfg = config[0]
+	}
+	keyFunc := cfg.Key
+	if keyFunc == nil {
+		keyFunc = KeyByClientIP()
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRedisRateLimitConfig.Timeout
+	}
+
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			key = "ip:" + ClientIP(c)
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		result, err := limiter.Allow(ctx, key)
+		cancel()
+
+		if err != nil {
+			logger.Ctx(c).WithError(err).WithField("fail_open", cfg.FailOpen).Warn("限流检查失败")
+			if cfg.FailOpen {
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"code":    http.StatusServiceUnavailable,
+				"message": "Service Unavailable",
+			})
+			return
+		}
+
+		setRateLimitHeaders(c, result.Limit, result.Remaining, result.Reset)
+		if !result.Allowed {
+			c.Header(HeaderRetryAfter, strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			c.Set(rateLimitedKey, true)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    http.StatusTooManyRequests,
+				"message": "Too Many Requests",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -5,16 +5,18 @@ package gin
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/NHYCRaymond/calorie/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/time/rate"
 )
 
 // 常量定义
@@ -34,7 +36,6 @@ var (
 		},
 	}
 	requestCounter uint64
-	logChan        = make(chan logrus.Fields, 1000)
 )
 
 // RequestConfig 请求配置
@@ -45,18 +46,37 @@ type RequestConfig struct {
 	Timeout time.Duration
 	// 请求ID的Header名称
 	RequestIDHeader string
+	// 链路追踪ID的Header名称，优先使用 W3C traceparent
+	TraceIDHeader string
 	// 是否启用请求日志
 	EnableRequestLog bool
 	// 是否启用安全头
 	EnableSecurityHeaders bool
-	// 请求限流配置
+	// 请求限流配置，RateLimit 为每秒请求数，小于等于 0 时不限流
 	RateLimit int
 	RateBurst int
+	// 限流键提取函数，为空时所有请求共用一个限流器
+	RateLimitKey KeyFunc
+	// 按路由覆盖的限流规则
+	RateLimitRoutes []RouteRateLimit
+	// 按键限流器的最大数量，默认 10000，超过时淘汰最久未使用的限流器
+	RateLimitCacheSize int
+	// 按键限流器的空闲过期时间，默认 10 分钟
+	RateLimitTTL time.Duration
 	// 请求大小限制
 	MaxBodySize int64
-	// 路径过滤
+	// 路径过滤，格式见 ParsePathRule，黑名单优先于 PathRules，白名单最后匹配
 	PathWhitelist []string
 	PathBlacklist []string
+	// 路径访问规则，按顺序匹配，第一条匹配的规则生效
+	PathRules []PathRule
+	// 是否输出路径规则的匹配结果，用于排查请求被允许或拒绝的原因
+	PathTrace bool
+	// 受信任代理的 IP 或网段，配置后只信任来自这些代理的 X-Forwarded-For/X-Real-IP，
+	// 解析结果写入上下文，通过 ClientIP(c) 获取；为空时使用直连地址
+	TrustedProxies []string
+	// 解析客户端 IP 的请求头，为空时使用 DefaultProxyHeaders
+	ProxyHeaders []string
 	// 请求头过滤
 	FilterHeaders []string
 	// 自定义标签
@@ -70,6 +90,7 @@ var DefaultRequestConfig = &RequestConfig{
 	ServiceName:           "default",
 	Timeout:               30 * time.Second,
 	RequestIDHeader:       "X-Request-ID",
+	TraceIDHeader:         "X-Trace-ID",
 	EnableRequestLog:      true,
 	EnableSecurityHeaders: true,
 	RateLimit:             1000,
@@ -85,18 +106,32 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 		config = DefaultRequestConfig
 	}
 
-	// 初始化限流器
-	limiter := rate.NewLimiter(rate.Limit(config.RateLimit), config.RateBurst)
+	// 初始化路径访问控制和限流器
+	paths, err := newPathMatcher(config)
+	if err != nil {
+		panic(fmt.Sprintf("gin: invalid path rules: %v", err))
+	}
+	limiter := newRequestRateLimiter(config)
 
-	// 启动日志处理协程
-	go processLogs()
+	// 初始化受信任代理
+	var proxies *TrustedProxies
+	if len(config.TrustedProxies) > 0 {
+		if proxies, err = NewTrustedProxies(config.TrustedProxies, config.ProxyHeaders...); err != nil {
+			panic(fmt.Sprintf("gin: invalid trusted proxies: %v", err))
+		}
+	}
 
 	return func(c *gin.Context) {
+		// 0. 按受信任代理解析客户端 IP
+		if proxies != nil {
+			c.Set(ClientIPKey, proxies.ClientIP(c.Request))
+		}
+
 		// 1. 请求大小限制
 		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxBodySize)
 
 		// 2. 路径过滤
-		if !isPathAllowed(c.Request.URL.Path, config) {
+		if !paths.allow(c) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"code":    http.StatusForbidden,
 				"message": "Access Denied",
@@ -105,11 +140,7 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 		}
 
 		// 3. 限流检查
-		if !limiter.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"code":    http.StatusTooManyRequests,
-				"message": "Too Many Requests",
-			})
+		if !limiter.allow(c) {
 			return
 		}
 
@@ -118,12 +149,19 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 		defer cancel()
 		c.Request = c.Request.WithContext(ctx)
 
-		// 5. 添加请求ID
+		// 5. 添加请求ID和链路追踪ID，写入上下文供后续日志使用
 		requestID := c.GetHeader(config.RequestIDHeader)
 		if requestID == "" {
 			requestID = uuid.New().String()
 			c.Header(config.RequestIDHeader, requestID)
 		}
+		logFields := logger.Fields{logger.FieldRequestID: requestID}
+		c.Set(logger.FieldRequestID, requestID)
+		if traceID := traceIDFrom(c, config); traceID != "" {
+			logFields[logger.FieldTraceID] = traceID
+			c.Set(logger.FieldTraceID, traceID)
+		}
+		AddLogFields(c, logFields)
 
 		// 6. 添加安全头
 		if config.EnableSecurityHeaders {
@@ -136,14 +174,11 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 		// 7. Panic 恢复
 		defer func() {
 			if err := recover(); err != nil {
-				stack := debug.Stack()
-				logFields := requestFieldsPool.Get().(logrus.Fields)
-				logFields["service"] = config.ServiceName
-				logFields["request_id"] = requestID
-				logFields["error"] = err
-				logFields["stack"] = string(stack)
-				logChan <- logFields
-				requestFieldsPool.Put(logFields)
+				logger.Ctx(c).WithFields(logrus.Fields{
+					"service": config.ServiceName,
+					"error":   err,
+					"stack":   string(debug.Stack()),
+				}).Error("请求处理 panic")
 
 				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 					"code":    http.StatusInternalServerError,
@@ -171,7 +206,7 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 			fields := requestFieldsPool.Get().(logrus.Fields)
 			fields["service"] = config.ServiceName
 			fields["request_id"] = requestID
-			fields["client_ip"] = c.ClientIP()
+			fields["client_ip"] = ClientIP(c)
 			fields["method"] = c.Request.Method
 			fields["path"] = path
 			fields["status"] = c.Writer.Status()
@@ -185,8 +220,9 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 				fields[k] = v
 			}
 
-			// 异步处理日志
-			logChan <- fields
+			// 日志是否异步输出由 pkg/logger 的 Async 配置决定
+			logRequest(c, fields)
+			requestFieldsPool.Put(fields)
 		}
 
 		// 11. 处理上下文超时
@@ -200,42 +236,34 @@ func RequestMiddleware(config *RequestConfig) gin.HandlerFunc {
 	}
 }
 
-// isPathAllowed 检查路径是否允许访问
-func isPathAllowed(path string, config *RequestConfig) bool {
-	// 检查黑名单
-	for _, blackPath := range config.PathBlacklist {
-		if path == blackPath {
-			return false
-		}
-	}
+// AddLogFields 将日志字段写入请求上下文
+// 之后通过 logger.Ctx(c) 或 logger.Ctx(c.Request.Context()) 输出的日志都会附带这些字段
+func AddLogFields(c *gin.Context, fields logger.Fields) {
+	c.Request = c.Request.WithContext(logger.ContextWithFields(c.Request.Context(), fields))
+}
 
-	// 如果白名单不为空，只允许白名单中的路径
-	if len(config.PathWhitelist) > 0 {
-		for _, whitePath := range config.PathWhitelist {
-			if path == whitePath {
-				return true
-			}
-		}
-		return false
+// traceIDFrom 获取链路追踪ID，优先解析 W3C traceparent，其次使用配置的 Header
+func traceIDFrom(c *gin.Context, config *RequestConfig) string {
+	// traceparent 格式：version-traceid-parentid-flags
+	if parts := strings.Split(c.GetHeader("traceparent"), "-"); len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
 	}
-
-	return true
+	if config.TraceIDHeader != "" {
+		return c.GetHeader(config.TraceIDHeader)
+	}
+	return ""
 }
 
-// processLogs 处理日志的协程
-func processLogs() {
-	for fields := range logChan {
-		// 根据状态码选择日志级别
-		status, _ := fields["status"].(int)
-		logger := logrus.WithFields(fields)
-		if status >= http.StatusInternalServerError {
-			logger.Error("请求处理失败")
-		} else if status >= http.StatusBadRequest {
-			logger.Warn("请求处理异常")
-		} else {
-			logger.Info("请求处理完成")
-		}
-		// 归还对象到池中
-		requestFieldsPool.Put(fields)
+// logRequest 根据状态码选择级别输出访问日志
+// 通过 pkg/logger 输出，使访问日志同样经过采样、脱敏和异步管道
+func logRequest(c *gin.Context, fields logrus.Fields) {
+	status, _ := fields["status"].(int)
+	entry := logger.Ctx(c).WithFields(fields)
+	if status >= http.StatusInternalServerError {
+		entry.Error("请求处理失败")
+	} else if status >= http.StatusBadRequest {
+		entry.Warn("请求处理异常")
+	} else {
+		entry.Info("请求处理完成")
 	}
 }
@@ -30,11 +30,13 @@ const (
 
 // Response 统一响应结构
 type Response struct {
-	Code      errors.ErrorCode `json:"code"`                 // 响应码
-	Message   string           `json:"message"`              // 响应消息
-	Data      interface{}      `json:"data,omitempty"`       // 响应数据
-	Timestamp int64            `json:"timestamp"`            // 时间戳
-	RequestID string           `json:"request_id,omitempty"` // 请求ID
+	Code      errors.ErrorCode    `json:"code"`                 // 响应码
+	Message   string              `json:"message"`              // 响应消息
+	Data      interface{}         `json:"data,omitempty"`       // 响应数据
+	Timestamp int64               `json:"timestamp"`            // 时间戳
+	RequestID string              `json:"request_id,omitempty"` // 请求ID
+	Details   []errors.FieldError `json:"details,omitempty"`    // 字段校验错误
+	Stack     string              `json:"stack,omitempty"`      // 调用栈，仅调试模式下返回
 }
 
 // Config 中间件配置
@@ -45,8 +47,96 @@ type Config struct {
 	RequestIDHeader string
 	// 自定义错误处理函数
 	ErrorHandler func(*gin.Context, error)
+	// 调试模式，开启后错误响应中会返回调用栈
+	Debug bool
+	// 错误码到 HTTP 状态码的映射，优先于 DefaultStatusMapping 和错误码注册表
+	StatusMapping map[errors.ErrorCode]int
+	// 未映射错误码的 HTTP 状态码回退规则，为空时使用 DefaultStatusFallback
+	StatusFallback func(errors.ErrorCode) int
+	// 是否始终返回 200 状态码（兼容旧客户端）
+	AlwaysOK bool
+	// 多语言错误消息包，为空时不做本地化
+	Bundle *errors.Bundle
+	// 错误响应格式，默认为 {code,message,data} 结构
+	Format ResponseFormat
+	// Problem 格式下 type 字段的 URI 前缀，拼接错误码生成 type，为空时使用 about:blank
+	ProblemTypeBase string
 }
 
+// ResponseFormat 错误响应格式
+type ResponseFormat int
+
+const (
+	// FormatEnvelope {code,message,data} 结构
+	FormatEnvelope ResponseFormat = iota
+	// FormatProblem RFC 7807 application/problem+json 结构，忽略 AlwaysOK
+	FormatProblem
+)
+
+// LocaleKey gin.Context 中指定响应语言的键，优先于 Accept-Language
+const LocaleKey = "locale"
+
+// DefaultStatusMapping 内置错误码的 HTTP 状态码映射
+var DefaultStatusMapping = map[errors.ErrorCode]int{
+	errors.CodeSuccess:       http.StatusOK,
+	errors.CodeError:         http.StatusBadRequest,
+	errors.CodeInvalidParams: http.StatusBadRequest,
+	errors.CodeUnauthorized:  http.StatusUnauthorized,
+	errors.CodeForbidden:     http.StatusForbidden,
+	errors.CodeNotFound:      http.StatusNotFound,
+	errors.CodeConflict:      http.StatusConflict,
+	errors.CodeServerError:   http.StatusInternalServerError,
+	errors.CodeUnavailable:   http.StatusServiceUnavailable,
+	errors.CodeTimeout:       http.StatusGatewayTimeout,
+}
+
+// DefaultStatusFallback 默认回退规则
+// 1. 错误码本身是合法的 HTTP 状态码时直接使用，如 409
+// 2. 五位错误码前三位是 4xx/5xx 状态码时使用前三位，如 40401 -> 404
+// 3. 其他情况返回 500
+func DefaultStatusFallback(code errors.ErrorCode) int {
+	if isHTTPStatus(int(code)) {
+		return int(code)
+	}
+	if status := int(code) / 100; code >= 10000 && code < 100000 && status >= 400 && isHTTPStatus(status) {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// isHTTPStatus 判断是否为合法的 HTTP 状态码
+func isHTTPStatus(status int) bool {
+	return status >= 100 && status < 600 && http.StatusText(status) != ""
+}
+
+// HTTPStatus 获取错误码对应的 HTTP 状态码
+func (c *Config) HTTPStatus(code errors.ErrorCode) int {
+	if c.AlwaysOK {
+		return http.StatusOK
+	}
+	return c.mappedStatus(code)
+}
+
+// mappedStatus 获取错误码映射的 HTTP 状态码，不受 AlwaysOK 影响
+func (c *Config) mappedStatus(code errors.ErrorCode) int {
+	if status, ok := c.StatusMapping[code]; ok {
+		return status
+	}
+	if status, ok := DefaultStatusMapping[code]; ok {
+		return status
+	}
+	if info, ok := errors.Lookup(code); ok && info.HTTPStatus != 0 {
+		return info.HTTPStatus
+	}
+	if c.StatusFallback != nil {
+		return c.StatusFallback(code)
+	}
+	return DefaultStatusFallback(code)
+}
+
+// configKey 中间件配置在 gin.Context 中的键
+const configKey = "calorie.response.config"
+
 // DefaultConfig 默认配置
 var DefaultConfig = &Config{
 	EnableRequestID: true,
@@ -63,27 +153,115 @@ func Error(c *gin.Context, code errors.ErrorCode, message string) {
 	response(c, code, nil, message)
 }
 
+// HandleError 根据错误生成错误响应
+// *errors.Error 使用其错误码和消息，参数校验错误返回 CodeInvalidParams 及字段错误列表，
+// 其他错误按服务器错误处理
+func HandleError(c *gin.Context, err error) {
+	e, ok := errors.FromError(err)
+	if !ok {
+		v, isValidation := ValidationErrorFrom(err)
+		if !isValidation {
+			Error(c, errors.CodeServerError, err.Error())
+			return
+		}
+		e = v.ToError()
+	}
+
+	render(c, e, nil, err)
+}
+
+// SetLocale 设置当前请求的响应语言
+func SetLocale(c *gin.Context, locale string) {
+	c.Set(LocaleKey, locale)
+}
+
 // response 统一响应处理
 func response(c *gin.Context, code errors.ErrorCode, data interface{}, messages ...string) {
-	message := errors.GetMessage(code)
+	e := &errors.Error{Code: code}
 	if len(messages) > 0 {
-		message = messages[0]
+		e.Message = messages[0]
+	}
+	render(c, e, data, nil)
+}
+
+// render 按配置的格式输出响应
+func render(c *gin.Context, e *errors.Error, data interface{}, err error) {
+	cfg := getConfig(c)
+	if cfg.Format == FormatProblem && e.Code != errors.CodeSuccess {
+		status := cfg.mappedStatus(e.Code)
+		c.Header("Content-Type", problemContentType)
+		c.JSON(status, newProblem(c, cfg, e, status, err))
+		return
+	}
+
+	resp := newResponse(c, e, data)
+	if err != nil {
+		if v, ok := ValidationErrorFrom(err); ok {
+			resp.Details = v.Fields
+		}
+		if cfg.Debug {
+			resp.Stack = errors.Stack(err)
+		}
 	}
+	c.JSON(cfg.HTTPStatus(e.Code), resp)
+}
 
+// newResponse 创建响应结构
+func newResponse(c *gin.Context, e *errors.Error, data interface{}) *Response {
+	cfg := getConfig(c)
 	resp := &Response{
-		Code:      code,
-		Message:   message,
+		Code:      e.Code,
+		Message:   localizedMessage(c, cfg, e),
 		Data:      data,
 		Timestamp: time.Now().Unix(),
 	}
 
-	if DefaultConfig.EnableRequestID {
-		if requestID := c.GetHeader(DefaultConfig.RequestIDHeader); requestID != "" {
-			resp.RequestID = requestID
+	if cfg.EnableRequestID {
+		resp.RequestID = requestID(c, cfg)
+	}
+
+	return resp
+}
+
+// requestID 获取请求ID，优先使用请求头，其次使用中间件生成并写入响应头的请求ID
+func requestID(c *gin.Context, cfg *Config) string {
+	if id := c.GetHeader(cfg.RequestIDHeader); id != "" {
+		return id
+	}
+	return c.Writer.Header().Get(cfg.RequestIDHeader)
+}
+
+// localizedMessage 获取错误消息
+// 配置了消息包时优先使用对应语言的消息，其次使用错误自带的消息，最后使用默认消息
+func localizedMessage(c *gin.Context, cfg *Config, e *errors.Error) string {
+	if cfg.Bundle != nil {
+		if msg, ok := cfg.Bundle.Localize(requestLocale(c, cfg.Bundle), e); ok {
+			return msg
 		}
 	}
+	if e.Message != "" {
+		return e.Message
+	}
+	return errors.GetMessage(e.Code)
+}
+
+// requestLocale 获取当前请求的语言
+// 优先使用 gin.Context 中的 LocaleKey，其次解析 Accept-Language，最后使用默认语言
+func requestLocale(c *gin.Context, bundle *errors.Bundle) string {
+	if locale := c.GetString(LocaleKey); locale != "" {
+		return bundle.Match(locale)
+	}
+	return bundle.Match(c.GetHeader("Accept-Language"))
+}
 
-	c.JSON(http.StatusOK, resp)
+// getConfig 获取当前请求使用的中间件配置
+func getConfig(c *gin.Context) *Config {
+	if v, ok := c.Get(configKey); ok {
+		if cfg, ok := v.(*Config); ok {
+			return cfg
+		}
+	}
+	return DefaultConfig
 }
 
 // ResponseMiddleware 响应中间件
@@ -94,13 +272,11 @@ func ResponseMiddleware(config ...*Config) gin.HandlerFunc {
 	}
 
 	return func(c *gin.Context) {
+		c.Set(configKey, cfg)
+
 		// 设置请求ID
-		if cfg.EnableRequestID {
-			requestID := c.GetHeader(cfg.RequestIDHeader)
-			if requestID == "" {
-				requestID = generateRequestID()
-				c.Header(cfg.RequestIDHeader, requestID)
-			}
+		if cfg.EnableRequestID && requestID(c, cfg) == "" {
+			c.Header(cfg.RequestIDHeader, generateRequestID())
 		}
 
 		// 错误处理
@@ -117,26 +293,25 @@ func ResponseMiddleware(config ...*Config) gin.HandlerFunc {
 			if cfg.ErrorHandler != nil {
 				cfg.ErrorHandler(c, lastError)
 			} else {
-				if e, ok := lastError.Err.(*errors.Error); ok {
-					Error(c, e.Code, e.Message)
-				} else {
-					Error(c, errors.CodeServerError, lastError.Error())
-				}
+				HandleError(c, lastError.Err)
 			}
 			return
 		}
 
 		// 获取响应状态码
+		// 2xx 按成功处理并保留原状态码，如 201、204；4xx/5xx 以状态码作为错误码，
+		// 未注册的状态码使用标准状态文本作为消息
 		status := c.Writer.Status()
-		switch status {
-		case http.StatusOK:
-			Success(c, nil)
-		case http.StatusUnauthorized:
-			Error(c, errors.CodeUnauthorized, "")
-		case http.StatusForbidden:
-			Error(c, errors.CodeForbidden, "")
-		case http.StatusNotFound:
-			Error(c, errors.CodeNotFound, "")
+		switch {
+		case status >= 200 && status < 300:
+			c.JSON(status, newResponse(c, &errors.Error{Code: errors.CodeSuccess}, nil))
+		case status >= 400 && status < 600:
+			code := errors.ErrorCode(status)
+			message := ""
+			if _, ok := errors.Lookup(code); !ok {
+				message = http.StatusText(status)
+			}
+			Error(c, code, message)
 		default:
 			Error(c, errors.CodeServerError, http.StatusText(status))
 		}
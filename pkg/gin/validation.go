@@ -0,0 +1,47 @@
+package gin
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationErrorFrom 从错误链中提取参数校验错误
+// 支持 *errors.ValidationError 和 gin 绑定时返回的 validator.ValidationErrors
+func ValidationErrorFrom(err error) (*errors.ValidationError, bool) {
+	var v *errors.ValidationError
+	if stderrors.As(err, &v) {
+		return v, true
+	}
+
+	var ves validator.ValidationErrors
+	if !stderrors.As(err, &ves) {
+		return nil, false
+	}
+
+	v = errors.NewValidation()
+	for _, fe := range ves {
+		v.Add(fieldPath(fe), fe.Tag(), fieldMessage(fe))
+	}
+	return v, true
+}
+
+// fieldPath 获取字段路径，去掉最外层结构体名称，如 CreateUserRequest.Emails[0] -> Emails[0]
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// fieldMessage 生成字段错误消息
+func fieldMessage(fe validator.FieldError) string {
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed on the '%s=%s' rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
+}
@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor 一元调用拦截器
+// 恢复 panic，并将返回的错误转换为 gRPC 状态
+func UnaryServerInterceptor(config ...*Config) grpc.UnaryServerInterceptor {
+	cfg := DefaultConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = cfg.ToStatus(cfg.recover(ctx, info.FullMethod, p)).Err()
+			}
+		}()
+
+		resp, err = handler(ctx, req)
+		if err != nil {
+			return resp, cfg.ToStatus(err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor 流式调用拦截器
+// 恢复 panic，并将返回的错误转换为 gRPC 状态
+func StreamServerInterceptor(config ...*Config) grpc.StreamServerInterceptor {
+	cfg := DefaultConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = cfg.ToStatus(cfg.recover(ss.Context(), info.FullMethod, p)).Err()
+			}
+		}()
+
+		if err = handler(srv, ss); err != nil {
+			return cfg.ToStatus(err).Err()
+		}
+		return nil
+	}
+}
+
+// recover 记录 panic 并生成接口错误
+func (c *Config) recover(ctx context.Context, method string, p interface{}) error {
+	logger.Ctx(ctx).WithFields(logrus.Fields{
+		"method": method,
+		"error":  p,
+		"stack":  string(debug.Stack()),
+	}).Error("gRPC 请求处理 panic")
+
+	if c.RecoveryHandler != nil {
+		return c.RecoveryHandler(ctx, p)
+	}
+	return errors.New(errors.CodeServerError, errors.GetMessage(errors.CodeServerError))
+}
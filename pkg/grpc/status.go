@@ -0,0 +1,314 @@
+// Package grpc provides error conversion and server interceptors for gRPC.
+// It maps pkg/errors to gRPC status codes so gRPC and Gin services share one error model.
+package grpc
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorInfo 元数据键
+const (
+	metadataDetailPrefix = "detail."     // 错误详情，按序号保存
+	metadataRulePrefix   = "field_rule." // 字段校验规则，与 BadRequest 中的字段按序号对应
+	metadataRetryable    = "retryable"   // 是否可重试
+)
+
+// Config 错误转换和拦截器配置
+type Config struct {
+	// ErrorInfo 的 domain，用于识别本服务生成的错误码
+	Domain string
+	// 调试模式，开启后在 DebugInfo 中返回原始错误信息和调用栈
+	Debug bool
+	// 错误码到 gRPC 状态码的映射，优先于 DefaultCodeMapping 和错误码注册表
+	CodeMapping map[errors.ErrorCode]codes.Code
+	// 自定义 panic 处理函数，返回值作为接口错误
+	RecoveryHandler func(ctx context.Context, p interface{}) error
+}
+
+// DefaultConfig 默认配置
+var DefaultConfig = &Config{
+	Domain: "calorie",
+}
+
+// DefaultCodeMapping 内置错误码到 gRPC 状态码的映射
+var DefaultCodeMapping = map[errors.ErrorCode]codes.Code{
+	errors.CodeSuccess:       codes.OK,
+	errors.CodeError:         codes.Unknown,
+	errors.CodeInvalidParams: codes.InvalidArgument,
+	errors.CodeUnauthorized:  codes.Unauthenticated,
+	errors.CodeForbidden:     codes.PermissionDenied,
+	errors.CodeNotFound:      codes.NotFound,
+	errors.CodeConflict:      codes.Aborted,
+	errors.CodeServerError:   codes.Internal,
+	errors.CodeUnavailable:   codes.Unavailable,
+	errors.CodeTimeout:       codes.DeadlineExceeded,
+}
+
+// ToStatus 使用默认配置将错误转换为 gRPC 状态
+func ToStatus(err error) *status.Status {
+	return DefaultConfig.ToStatus(err)
+}
+
+// FromStatus 使用默认配置将 gRPC 状态转换为错误
+func FromStatus(st *status.Status) *errors.Error {
+	return DefaultConfig.FromStatus(st)
+}
+
+// FromError 使用默认配置将 gRPC 客户端返回的错误转换为 *errors.Error
+// err 为 nil 时返回 nil
+func FromError(err error) *errors.Error {
+	if err == nil {
+		return nil
+	}
+	return DefaultConfig.FromStatus(status.Convert(err))
+}
+
+// ToStatus 将错误转换为 gRPC 状态
+// 错误码写入 ErrorInfo，错误详情写入 ErrorInfo 元数据，字段校验错误写入 BadRequest；
+// 本服务的 *errors.Error 优先于其包装的下游 gRPC 状态，其他错误的原始信息只在调试模式下写入 DebugInfo
+func (c *Config) ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	e, ok := errors.FromError(err)
+	if !ok {
+		var v *errors.ValidationError
+		if stderrors.As(err, &v) {
+			e = v.ToError()
+		} else if st, ok := status.FromError(err); ok {
+			return st
+		} else if stderrors.Is(err, context.Canceled) {
+			return status.New(codes.Canceled, context.Canceled.Error())
+		} else if stderrors.Is(err, context.DeadlineExceeded) {
+			return status.New(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+		} else {
+			e = errors.Wrap(err, errors.CodeServerError, errors.GetMessage(errors.CodeServerError))
+		}
+	}
+
+	message := e.Message
+	if message == "" {
+		message = errors.GetMessage(e.Code)
+	}
+	st := status.New(c.grpcCode(e), message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   strconv.Itoa(int(e.Code)),
+		Domain:   c.Domain,
+		Metadata: make(map[string]string),
+	}
+	for i, detail := range e.Details {
+		info.Metadata[metadataDetailPrefix+strconv.Itoa(i)] = detail
+	}
+	if e.Retryable() {
+		info.Metadata[metadataRetryable] = "true"
+	}
+	details := []protoadapt.MessageV1{info}
+
+	var v *errors.ValidationError
+	if stderrors.As(err, &v) && v.HasErrors() {
+		badRequest := &errdetails.BadRequest{}
+		for i, f := range v.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+			info.Metadata[metadataRulePrefix+strconv.Itoa(i)] = f.Rule
+		}
+		details = append(details, badRequest)
+	}
+
+	if c.Debug {
+		debug := &errdetails.DebugInfo{Detail: err.Error()}
+		if trace := errors.Stack(err); trace != "" {
+			debug.StackEntries = strings.Split(strings.TrimSpace(trace), "\n")
+		}
+		details = append(details, debug)
+	}
+
+	if withDetails, detailErr := st.WithDetails(details...); detailErr == nil {
+		st = withDetails
+	}
+	return st
+}
+
+// FromStatus 将 gRPC 状态转换为错误
+// 优先使用本服务 domain 下 ErrorInfo 中的错误码，否则根据 gRPC 状态码推断
+func (c *Config) FromStatus(st *status.Status) *errors.Error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	code := errorCode(st.Code())
+	var metadata map[string]string
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() != c.Domain {
+				continue
+			}
+			if v, err := strconv.Atoi(d.GetReason()); err == nil {
+				code = errors.ErrorCode(v)
+			}
+			metadata = d.GetMetadata()
+		case *errdetails.BadRequest:
+			violations = append(violations, d.GetFieldViolations()...)
+		}
+	}
+
+	var e *errors.Error
+	if len(violations) > 0 {
+		v := errors.NewValidation()
+		for i, fv := range violations {
+			v.Add(fv.GetField(), metadata[metadataRulePrefix+strconv.Itoa(i)], fv.GetDescription())
+		}
+		e = errors.Wrap(v, code, st.Message())
+	} else {
+		e = errors.Wrap(st.Err(), code, st.Message())
+	}
+
+	e.Details = metadataDetails(metadata)
+	if kind := kindOf(st.Code()); kind != errors.KindUnknown {
+		e.WithKind(kind)
+	}
+	if _, ok := metadata[metadataRetryable]; ok {
+		e.WithRetryable(true)
+	}
+	return e
+}
+
+// grpcCode 获取错误对应的 gRPC 状态码
+func (c *Config) grpcCode(e *errors.Error) codes.Code {
+	if code, ok := c.CodeMapping[e.Code]; ok {
+		return code
+	}
+	if code, ok := DefaultCodeMapping[e.Code]; ok {
+		return code
+	}
+	if info, ok := errors.Lookup(e.Code); ok && info.HTTPStatus != 0 {
+		return httpStatusToCode(info.HTTPStatus)
+	}
+
+	switch errors.KindOf(e) {
+	case errors.KindTimeout:
+		return codes.DeadlineExceeded
+	case errors.KindUnavailable:
+		return codes.Unavailable
+	case errors.KindNotFound:
+		return codes.NotFound
+	case errors.KindConflict:
+		return codes.Aborted
+	}
+	return codes.Unknown
+}
+
+// httpStatusToCode HTTP 状态码转换为 gRPC 状态码
+func httpStatusToCode(status int) codes.Code {
+	switch status {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if status >= 400 && status < 500 {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
+
+// errorCode gRPC 状态码转换为内置错误码
+func errorCode(code codes.Code) errors.ErrorCode {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return errors.CodeInvalidParams
+	case codes.Unauthenticated:
+		return errors.CodeUnauthorized
+	case codes.PermissionDenied:
+		return errors.CodeForbidden
+	case codes.NotFound:
+		return errors.CodeNotFound
+	case codes.Aborted, codes.AlreadyExists:
+		return errors.CodeConflict
+	case codes.Unavailable:
+		return errors.CodeUnavailable
+	case codes.DeadlineExceeded:
+		return errors.CodeTimeout
+	default:
+		return errors.CodeServerError
+	}
+}
+
+// kindOf gRPC 状态码转换为错误分类
+func kindOf(code codes.Code) errors.Kind {
+	switch code {
+	case codes.DeadlineExceeded:
+		return errors.KindTimeout
+	case codes.Unavailable:
+		return errors.KindUnavailable
+	case codes.NotFound:
+		return errors.KindNotFound
+	case codes.Aborted, codes.AlreadyExists:
+		return errors.KindConflict
+	default:
+		return errors.KindUnknown
+	}
+}
+
+// metadataDetails 从 ErrorInfo 元数据中按序号还原错误详情
+func metadataDetails(metadata map[string]string) []string {
+	type indexed struct {
+		index  int
+		detail string
+	}
+
+	var items []indexed
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, metadataDetailPrefix) {
+			continue
+		}
+		if i, err := strconv.Atoi(strings.TrimPrefix(key, metadataDetailPrefix)); err == nil {
+			items = append(items, indexed{index: i, detail: value})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].index < items[j].index
+	})
+
+	details := make([]string, 0, len(items))
+	for _, item := range items {
+		details = append(details, item.detail)
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/NHYCRaymond/calorie/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// 缓冲区已满时的处理策略
+const (
+	PolicyBlock      = "block"       // 阻塞等待，不丢弃日志
+	PolicyDropNewest = "drop_newest" // 丢弃新日志
+	PolicyDropOldest = "drop_oldest" // 丢弃缓冲区中最早的日志
+)
+
+// AsyncConfig 异步日志配置
+// 开启后日志写入缓冲区，由后台协程脱敏并输出到各目标，调用方不会被慢速的输出目标阻塞
+// fatal 和 panic 级别的日志会先等待缓冲区写完，再同步输出
+type AsyncConfig struct {
+	BufferSize int    // 缓冲区大小，默认 8192
+	Policy     string // 缓冲区已满时的处理策略，默认 PolicyBlock
+
+	// 监控客户端，不为 nil 时记录丢弃的日志条数
+	Metrics *metrics.Client
+}
+
+// flushWaiter 等待中的 Flush，完成的日志条数达到 seq 时关闭 done
+type flushWaiter struct {
+	seq  uint64
+	done chan struct{}
+}
+
+// asyncPipeline 异步日志管道
+// 写入缓冲区前 submitted 加一，输出或丢弃后 completed 加一；
+// Flush 记下当前的 submitted 并等待 completed 追上，不占用缓冲区
+type asyncPipeline struct {
+	policy  string
+	queue   chan *logrus.Entry
+	process func(*logrus.Entry)
+
+	submitted atomic.Uint64
+	completed atomic.Uint64
+
+	waitMu  sync.Mutex
+	waiters []flushWaiter
+	waiting atomic.Int32
+
+	mu     sync.RWMutex // 保护 closed，发送时持有读锁，关闭时持有写锁
+	closed bool
+	done   chan struct{}
+
+	dropped        atomic.Uint64
+	droppedCounter *prometheus.CounterVec
+	service        string
+}
+
+// newAsyncPipeline 创建异步日志管道并启动后台协程
+func newAsyncPipeline(config *AsyncConfig, service string, process func(*logrus.Entry)) (*asyncPipeline, error) {
+	p := &asyncPipeline{
+		policy:  config.Policy,
+		process: process,
+		done:    make(chan struct{}),
+		service: service,
+	}
+
+	switch p.policy {
+	case "":
+		p.policy = PolicyBlock
+	case PolicyBlock, PolicyDropNewest, PolicyDropOldest:
+	default:
+		return nil, fmt.Errorf("async: unknown policy %q", config.Policy)
+	}
+
+	size := config.BufferSize
+	if size <= 0 {
+		size = 8192
+	}
+	p.queue = make(chan *logrus.Entry, size)
+
+	if config.Metrics != nil {
+		p.droppedCounter = config.Metrics.Counter(
+			"log_entries_dropped_total",
+			"Total number of log entries dropped because the async buffer was full",
+			[]string{"service", "level", "policy"},
+		)
+	}
+
+	go p.run()
+	return p, nil
+}
+
+// run 后台输出日志
+func (p *asyncPipeline) run() {
+	defer close(p.done)
+	for entry := range p.queue {
+		p.process(entry)
+		p.complete()
+	}
+}
+
+// complete 记录一条日志已输出或已丢弃，唤醒已完成的 Flush
+func (p *asyncPipeline) complete() {
+	n := p.completed.Add(1)
+	if p.waiting.Load() == 0 {
+		return
+	}
+
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+	waiters := p.waiters[:0]
+	for _, w := range p.waiters {
+		if w.seq <= n {
+			close(w.done)
+		} else {
+			waiters = append(waiters, w)
+		}
+	}
+	p.waiters = waiters
+	p.waiting.Store(int32(len(waiters)))
+}
+
+// enqueue 写入缓冲区，管道已关闭时同步输出
+func (p *asyncPipeline) enqueue(entry *logrus.Entry) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.process(entry)
+		return
+	}
+
+	p.submitted.Add(1)
+	switch p.policy {
+	case PolicyDropNewest:
+		select {
+		case p.queue <- entry:
+		default:
+			p.drop(entry)
+		}
+	case PolicyDropOldest:
+		for {
+			select {
+			case p.queue <- entry:
+				return
+			default:
+			}
+			select {
+			case old := <-p.queue:
+				p.drop(old)
+			default:
+			}
+		}
+	default:
+		p.queue <- entry
+	}
+}
+
+// drop 记录丢弃的日志
+func (p *asyncPipeline) drop(entry *logrus.Entry) {
+	p.dropped.Add(1)
+	if p.droppedCounter != nil {
+		p.droppedCounter.WithLabelValues(p.service, entry.Level.String(), p.policy).Inc()
+	}
+	p.complete()
+}
+
+// flush 等待缓冲区中已有的日志输出完成
+func (p *asyncPipeline) flush(ctx context.Context) error {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return nil
+	}
+
+	w := flushWaiter{seq: p.submitted.Load(), done: make(chan struct{})}
+	p.waitMu.Lock()
+	// 先登记再检查 completed，与 complete 中先计数再检查 waiting 的顺序相反，
+	// 保证两者至少有一方看到对方
+	p.waiting.Add(1)
+	if p.completed.Load() >= w.seq {
+		p.waiting.Add(-1)
+		p.waitMu.Unlock()
+		return nil
+	}
+	p.waiters = append(p.waiters, w)
+	p.waitMu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		p.removeWaiter(w)
+		return ctx.Err()
+	}
+}
+
+// removeWaiter 移除超时的 Flush
+func (p *asyncPipeline) removeWaiter(w flushWaiter) {
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+	for i, v := range p.waiters {
+		if v.done == w.done {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			p.waiting.Add(-1)
+			return
+		}
+	}
+}
+
+// close 停止接收新日志，并等待缓冲区中的日志输出完成
+// 之后写入的日志会同步输出
+func (p *asyncPipeline) close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 常用的上下文日志字段
+const (
+	FieldRequestID = "request_id" // 请求ID
+	FieldTraceID   = "trace_id"   // 链路追踪ID
+	FieldUserID    = "user_id"    // 用户ID
+)
+
+// wellKnownFields 通过 gin.Context.Set 等方式以字符串键写入上下文的常用字段
+var wellKnownFields = []string{FieldRequestID, FieldTraceID, FieldUserID}
+
+// fieldsKey 上下文中日志字段的键
+type fieldsKey struct{}
+
+// loggerKey 上下文中日志实例的键
+type loggerKey struct{}
+
+// ContextWithFields 返回携带日志字段的新上下文，新字段与已有字段合并
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	existing := FieldsFromContext(ctx)
+	merged := make(Fields, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// ContextWithField 返回携带单个日志字段的新上下文
+func ContextWithField(ctx context.Context, key string, value interface{}) context.Context {
+	return ContextWithFields(ctx, Fields{key: value})
+}
+
+// ContextWithRequestID 返回携带请求ID的新上下文
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return ContextWithField(ctx, FieldRequestID, requestID)
+}
+
+// ContextWithTraceID 返回携带链路追踪ID的新上下文
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return ContextWithField(ctx, FieldTraceID, traceID)
+}
+
+// ContextWithUserID 返回携带用户ID的新上下文
+func ContextWithUserID(ctx context.Context, userID interface{}) context.Context {
+	return ContextWithField(ctx, FieldUserID, userID)
+}
+
+// FieldsFromContext 获取上下文中的日志字段，返回值不应被修改
+func FieldsFromContext(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).(Fields); ok {
+		return fields
+	}
+
+	// gin.Context 默认不会回退到 Request.Context()，通过 Value(0) 获取原始请求
+	if req, ok := ctx.Value(0).(*http.Request); ok && req != nil {
+		if fields, ok := req.Context().Value(fieldsKey{}).(Fields); ok {
+			return fields
+		}
+	}
+	return nil
+}
+
+// FromContext 返回附带上下文日志字段的日志条目
+// 包括 ContextWithFields 写入的字段，以及以字符串键写入的 request_id、trace_id、user_id
+func (l *Logger) FromContext(ctx context.Context) *logrus.Entry {
+	if ctx == nil {
+		return l.entry
+	}
+
+	fields := make(Fields)
+	for k, v := range FieldsFromContext(ctx) {
+		fields[k] = v
+	}
+	for _, key := range wellKnownFields {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		if v := ctx.Value(key); v != nil {
+			fields[key] = v
+		}
+	}
+
+	return l.entry.WithContext(ctx).WithFields(fields)
+}
+
+// Ctx FromContext 的简写
+func (l *Logger) Ctx(ctx context.Context) *logrus.Entry {
+	return l.FromContext(ctx)
+}
+
+// ContextWithLogger 返回携带日志实例的新上下文
+// 包级的 FromContext 和 Ctx 会优先使用上下文中的日志实例，如测试中为每个请求注入独立的实例
+func ContextWithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext 获取上下文中的日志实例，不存在时返回默认日志实例
+func LoggerFromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok && l != nil {
+			return l
+		}
+		if req, ok := ctx.Value(0).(*http.Request); ok && req != nil {
+			if l, ok := req.Context().Value(loggerKey{}).(*Logger); ok && l != nil {
+				return l
+			}
+		}
+	}
+	return Default()
+}
+
+// FromContext 返回附带上下文日志字段的日志条目
+// 优先使用上下文中的日志实例，不存在时使用默认日志实例
+func FromContext(ctx context.Context) *logrus.Entry {
+	return LoggerFromContext(ctx).FromContext(ctx)
+}
+
+// Ctx FromContext 的简写
+//
+//	logger.Ctx(ctx).Info("用户登录成功")
+func Ctx(ctx context.Context) *logrus.Entry {
+	return LoggerFromContext(ctx).FromContext(ctx)
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levelRequest 修改日志级别的请求
+type levelRequest struct {
+	Module      string `json:"module"`       // 模块名称，为空时修改全局级别
+	Level       string `json:"level"`        // 日志级别
+	RevertAfter string `json:"revert_after"` // 自动恢复时间，如 "10m"
+}
+
+// LevelHandler 日志级别管理接口
+//   - GET    返回全局和各模块的日志级别
+//   - PUT    修改日志级别，参数可以通过 JSON 请求体或查询参数传递：
+//     module 模块名称（为空时修改全局级别）、level 日志级别、revert_after 自动恢复时间
+//   - DELETE 取消模块单独设置的级别（查询参数 module）
+//
+// 可以挂载到 gin 路由或监控服务上：
+//
+//	router.Any("/debug/log/level", gin.WrapH(logger.NewLevelHandler(nil)))
+//	http.Handle("/debug/log/level", logger.NewLevelHandler(nil))
+//
+// 该接口可以修改线上日志级别，应只在内部端口暴露或配合鉴权中间件使用
+type LevelHandler struct {
+	logger *Logger
+}
+
+// NewLevelHandler 创建日志级别管理接口，l 为 nil 时管理默认日志实例
+func NewLevelHandler(l *Logger) *LevelHandler {
+	return &LevelHandler{logger: l}
+}
+
+// ServeHTTP 实现 http.Handler 接口
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l := h.logger
+	if l == nil {
+		l = Default()
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		req, err := parseLevelRequest(r)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		level, err := logrus.ParseLevel(req.Level)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		var revertAfter time.Duration
+		if req.RevertAfter != "" {
+			if revertAfter, err = time.ParseDuration(req.RevertAfter); err != nil || revertAfter < 0 {
+				writeLevelError(w, http.StatusBadRequest, fmt.Errorf("invalid revert_after: %q", req.RevertAfter))
+				return
+			}
+		}
+
+		l.SetLevelFor(req.Module, level, revertAfter)
+		fields := Fields{"new_level": level.String(), "revert_after": revertAfter.String()}
+		if req.Module != "" {
+			fields[FieldModule] = req.Module
+		}
+		l.WithFields(fields).Warn("日志级别已修改")
+	case http.MethodDelete:
+		module := r.URL.Query().Get("module")
+		if module == "" {
+			writeLevelError(w, http.StatusBadRequest, fmt.Errorf("module is required"))
+			return
+		}
+		l.ResetModuleLevel(module)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	writeLevelJSON(w, http.StatusOK, l.LevelStatus())
+}
+
+// parseLevelRequest 解析修改日志级别的请求，查询参数优先于请求体
+func parseLevelRequest(r *http.Request) (*levelRequest, error) {
+	req := &levelRequest{}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return nil, fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+
+	query := r.URL.Query()
+	if v := query.Get("module"); v != "" {
+		req.Module = v
+	}
+	if v := query.Get("level"); v != "" {
+		req.Level = v
+	}
+	if v := query.Get("revert_after"); v != "" {
+		req.RevertAfter = v
+	}
+	return req, nil
+}
+
+// writeLevelJSON 输出 JSON 响应
+func writeLevelJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeLevelError 输出错误响应
+func writeLevelError(w http.ResponseWriter, status int, err error) {
+	writeLevelJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldModule 模块名称字段
+const FieldModule = "module"
+
+// LevelStatus 日志级别状态
+type LevelStatus struct {
+	Level    string                 `json:"level"`               // 全局级别
+	RevertAt *time.Time             `json:"revert_at,omitempty"` // 全局级别自动恢复时间
+	Modules  map[string]ModuleLevel `json:"modules,omitempty"`   // 各模块级别
+}
+
+// ModuleLevel 模块日志级别状态
+type ModuleLevel struct {
+	Level    string     `json:"level"`               // 当前生效的级别
+	Override bool       `json:"override"`            // 是否单独设置了级别，否则跟随全局级别
+	RevertAt *time.Time `json:"revert_at,omitempty"` // 自动恢复时间
+}
+
+// levelState 单个级别设置及其自动恢复计划
+type levelState struct {
+	override bool         // 是否单独设置了级别，仅用于模块
+	level    logrus.Level // 单独设置的级别
+	timer    *time.Timer  // 自动恢复定时器
+	revertAt time.Time    // 自动恢复时间
+}
+
+// levelController 管理根实例和模块实例的日志级别
+type levelController struct {
+	mu      sync.Mutex
+	root    *logrus.Logger
+	global  levelState
+	modules map[string]*moduleEntry
+}
+
+// moduleEntry 模块日志实例及其级别设置
+type moduleEntry struct {
+	logger *Logger
+	state  levelState
+}
+
+// newLevelController 创建级别控制器
+func newLevelController(root *logrus.Logger) *levelController {
+	return &levelController{
+		root:    root,
+		modules: make(map[string]*moduleEntry),
+	}
+}
+
+// Module 获取模块日志实例，同一名称返回同一实例
+// 模块实例与根实例共享输出和格式，日志附带 module 字段，级别可以单独设置
+//
+//	var log = logger.Module("redis")
+//	log.Debug("连接池已创建")
+func (l *Logger) Module(name string) *Logger {
+	c := l.levels
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if m, ok := c.modules[name]; ok {
+		return m.logger
+	}
+
+	log := logrus.New()
+	log.Out = c.root.Out
+	log.Formatter = c.root.Formatter
+	log.Hooks = c.root.Hooks
+	log.ReportCaller = c.root.ReportCaller
+	log.ExitFunc = c.root.ExitFunc
+	log.Level = c.root.GetLevel()
+
+	m := &moduleEntry{
+		logger: &Logger{
+			logger: log,
+			entry:  logrus.NewEntry(log).WithFields(l.entry.Data).WithField(FieldModule, name),
+			sinks:  l.sinks,
+			levels: c,
+			module: name,
+		},
+	}
+	c.modules[name] = m
+	return m.logger
+}
+
+// SetModuleLevel 设置模块的日志级别
+func (l *Logger) SetModuleLevel(module string, level logrus.Level) {
+	l.Module(module)
+	l.levels.set(module, level, 0)
+}
+
+// ResetModuleLevel 取消模块单独设置的级别，恢复跟随全局级别
+func (l *Logger) ResetModuleLevel(module string) {
+	l.levels.reset(module)
+}
+
+// SetLevelFor 设置日志级别，并在 d 时间后自动恢复为设置前的状态
+// module 为空时设置全局级别；d 不大于 0 时不会自动恢复
+func (l *Logger) SetLevelFor(module string, level logrus.Level, d time.Duration) {
+	if module != "" {
+		l.Module(module)
+	}
+	l.levels.set(module, level, d)
+}
+
+// LevelStatus 获取全局和各模块的日志级别
+func (l *Logger) LevelStatus() LevelStatus {
+	return l.levels.status()
+}
+
+// set 设置级别，module 为空时表示全局级别
+func (c *levelController) set(module string, level logrus.Level, revertAfter time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state(module)
+	if state == nil {
+		return
+	}
+
+	previous := *state
+	previous.timer = nil
+	previous.revertAt = time.Time{}
+	if module == "" {
+		previous.level = c.root.GetLevel()
+	}
+
+	c.apply(module, levelState{override: true, level: level})
+
+	if revertAfter > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(revertAfter, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			// 期间重新设置过级别时不再恢复
+			if s := c.state(module); s != nil && s.timer == timer {
+				c.apply(module, previous)
+			}
+		})
+		state.timer = timer
+		state.revertAt = time.Now().Add(revertAfter)
+	}
+}
+
+// reset 取消模块单独设置的级别
+func (c *levelController) reset(module string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.modules[module]; ok {
+		c.apply(module, levelState{})
+	}
+}
+
+// apply 应用级别设置，并取消已有的自动恢复计划，调用方需持有锁
+func (c *levelController) apply(module string, next levelState) {
+	state := c.state(module)
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	*state = next
+
+	if module == "" {
+		c.root.SetLevel(next.level)
+		for _, m := range c.modules {
+			if !m.state.override {
+				m.logger.logger.SetLevel(next.level)
+			}
+		}
+		return
+	}
+
+	level := c.root.GetLevel()
+	if next.override {
+		level = next.level
+	}
+	c.modules[module].logger.logger.SetLevel(level)
+}
+
+// state 获取级别设置，调用方需持有锁
+func (c *levelController) state(module string) *levelState {
+	if module == "" {
+		return &c.global
+	}
+	if m, ok := c.modules[module]; ok {
+		return &m.state
+	}
+	return nil
+}
+
+// status 获取级别状态
+func (c *levelController) status() LevelStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := LevelStatus{
+		Level:    c.root.GetLevel().String(),
+		RevertAt: revertAt(c.global),
+	}
+
+	if len(c.modules) > 0 {
+		status.Modules = make(map[string]ModuleLevel, len(c.modules))
+	}
+	for name, m := range c.modules {
+		status.Modules[name] = ModuleLevel{
+			Level:    m.logger.logger.GetLevel().String(),
+			Override: m.state.override,
+			RevertAt: revertAt(m.state),
+		}
+	}
+	return status
+}
+
+// revertAt 获取自动恢复时间，没有自动恢复计划时返回 nil
+func revertAt(state levelState) *time.Time {
+	if state.timer == nil {
+		return nil
+	}
+	t := state.revertAt
+	return &t
+}
+
+// Module 从默认日志实例获取模块日志实例
+func Module(name string) *Logger {
+	return Default().Module(name)
+}
@@ -1,138 +1,338 @@
 // Package logger provides a logrus-based logging utility with rotation support.
-// It supports daily rotation and size-based rotation (default 64MB).
+// Log files rotate daily or hourly with date-stamped names, are split by size
+// within a period (default 64MB), and old files are compressed and pruned by
+// count and age.
+//
+// 既可以通过 New 创建独立的 Logger 实例，也可以使用包级函数，
+// 包级函数委托给可替换的默认 Logger（见 Default 和 SetDefault）。
 package logger
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/NHYCRaymond/calorie/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var (
-	log *logrus.Logger
-	mu  sync.RWMutex
-)
+// Fields 日志字段
+type Fields = logrus.Fields
 
 // Config 日志配置
 type Config struct {
 	LogPath     string // 日志文件路径
+	FileName    string // 日志文件名前缀，默认 app
+	Rotation    string // 轮转周期，RotateDaily 或 RotateHourly，默认按天
 	MaxSize     int    // 单个日志文件最大大小（MB）
 	MaxBackups  int    // 保留的旧日志文件最大数量
 	MaxAge      int    // 保留的旧日志文件最大天数
 	Compress    bool   // 是否压缩旧日志文件
 	Level       string // 日志级别
 	ServiceName string // 服务名称
+
+	// 日志输出目标，为空时输出到控制台和日志文件
+	Sinks []SinkConfig
+	// 日志采样，为 nil 时不采样
+	Sampling *SamplingConfig
+	// 日志脱敏，为 nil 时不脱敏，可以使用 DefaultRedactionConfig
+	Redaction *RedactionConfig
+	// 异步输出，为 nil 时同步输出
+	Async *AsyncConfig
 }
 
-// InitLogger 初始化日志配置
-func InitLogger(config *Config) {
-	mu.Lock()
-	defer mu.Unlock()
+// Logger 日志实例
+// Logger 是协程安全的，可以在多个 goroutine 中共享
+type Logger struct {
+	logger *logrus.Logger
+	entry  *logrus.Entry
+	sinks  *dispatcher      // 输出目标，与模块日志实例共享
+	levels *levelController // 与模块日志实例共享的级别控制
+	module string           // 模块名称，根实例为空
+}
 
-	if log != nil {
-		return
+// New 根据配置创建日志实例
+// 日志按 Sinks 配置分发到各输出目标，未配置时同时输出到控制台和日志文件
+func New(config *Config) (*Logger, error) {
+	if config == nil {
+		config = DefaultConfig
+	}
+
+	sinks := config.Sinks
+	if len(sinks) == 0 {
+		sinks = []SinkConfig{{Type: SinkStdout}, {Type: SinkFile}}
+	}
+	var s *sampler
+	if config.Sampling != nil {
+		var err error
+		if s, err = newSampler(config.Sampling); err != nil {
+			return nil, err
+		}
+	}
+
+	var r *redactor
+	if config.Redaction != nil {
+		var err error
+		if r, err = newRedactor(config.Redaction); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := newDispatcher(config, sinks)
+	if err != nil {
+		return nil, err
+	}
+	d.redactor = r
+
+	if config.Async != nil {
+		if d.async, err = newAsyncPipeline(config.Async, config.ServiceName, d.writeAsync); err != nil {
+			d.Close()
+			return nil, err
+		}
 	}
 
-	log = logrus.New()
+	l := newLogger(d, config.Level, config.ServiceName)
+	if s != nil {
+		d.sampler = s
+		s.start(l.entry)
+	}
+	return l, nil
+}
 
-	// 设置日志格式为 JSON
-	log.SetFormatter(&logrus.JSONFormatter{
-		TimestampFormat: time.RFC3339,
-	})
+// newLogger 创建输出到指定目标的日志实例
+func newLogger(d *dispatcher, level, serviceName string) *Logger {
+	// 底层实例不直接输出，由 dispatcher 按各目标的级别和格式输出
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	log.SetFormatter(discardFormatter{})
+	log.AddHook(d)
 
 	// 设置日志级别
-	level, err := logrus.ParseLevel(config.Level)
+	lvl, err := logrus.ParseLevel(level)
 	if err != nil {
-		level = logrus.InfoLevel
+		lvl = logrus.InfoLevel
 	}
-	log.SetLevel(level)
+	log.SetLevel(lvl)
 
-	// 确保日志目录存在
-	if err := os.MkdirAll(config.LogPath, 0755); err != nil {
-		logrus.Fatal("Failed to create log directory:", err)
+	// 服务名称作为基础字段添加到每条日志
+	entry := logrus.NewEntry(log)
+	if serviceName != "" {
+		entry = entry.WithField("service", serviceName)
 	}
 
-	// 创建日志文件
-	logFile := &lumberjack.Logger{
-		Filename:   filepath.Join(config.LogPath, "app.log"),
-		MaxSize:    config.MaxSize,
-		MaxBackups: config.MaxBackups,
-		MaxAge:     config.MaxAge,
-		Compress:   config.Compress,
+	return &Logger{
+		logger: log,
+		entry:  entry,
+		sinks:  d,
+		levels: newLevelController(log),
 	}
+}
+
+// Debug 输出调试日志
+func (l *Logger) Debug(args ...interface{}) {
+	l.entry.Debug(args...)
+}
+
+// Info 输出信息日志
+func (l *Logger) Info(args ...interface{}) {
+	l.entry.Info(args...)
+}
+
+// Warn 输出警告日志
+func (l *Logger) Warn(args ...interface{}) {
+	l.entry.Warn(args...)
+}
+
+// Error 输出错误日志
+func (l *Logger) Error(args ...interface{}) {
+	l.entry.Error(args...)
+}
+
+// Fatal 输出致命错误日志
+func (l *Logger) Fatal(args ...interface{}) {
+	l.entry.Fatal(args...)
+}
 
-	// 同时输出到文件和控制台
-	log.SetOutput(io.MultiWriter(os.Stdout, logFile))
+// WithField 添加单个字段到日志
+func (l *Logger) WithField(key string, value interface{}) *logrus.Entry {
+	return l.entry.WithField(key, value)
+}
+
+// WithFields 添加字段到日志
+func (l *Logger) WithFields(fields Fields) *logrus.Entry {
+	return l.entry.WithFields(fields)
+}
 
-	// 添加服务名称字段
-	if config.ServiceName != "" {
-		log = log.WithField("service", config.ServiceName).Logger
+// WithError 添加错误字段到日志
+// 仅在 debug 级别下附带错误的调用栈
+func (l *Logger) WithError(err error) *logrus.Entry {
+	entry := l.entry.WithError(err)
+	if l.logger.IsLevelEnabled(logrus.DebugLevel) {
+		if trace := errors.Stack(err); trace != "" {
+			entry = entry.WithField("stack", trace)
+		}
+	}
+	return entry
+}
+
+// SetLevel 设置日志级别
+// 根实例设置全局级别，未单独设置级别的模块跟随变化；模块实例只设置本模块的级别
+func (l *Logger) SetLevel(level logrus.Level) {
+	l.levels.set(l.module, level, 0)
+}
+
+// GetLevel 获取日志级别
+func (l *Logger) GetLevel() logrus.Level {
+	return l.logger.GetLevel()
+}
+
+// IsLevelEnabled 判断指定级别的日志是否会输出
+func (l *Logger) IsLevelEnabled(level logrus.Level) bool {
+	return l.logger.IsLevelEnabled(level)
+}
+
+// Logrus 返回底层的 *logrus.Logger 实例
+// 注意：直接修改底层实例会影响所有共享该实例的日志
+// 底层实例的 Out 和 Formatter 不再生效，输出由 Sinks 配置决定
+func (l *Logger) Logrus() *logrus.Logger {
+	return l.logger
+}
+
+// Flush 等待缓冲中的日志全部输出，用于优雅退出前或定期落盘
+func (l *Logger) Flush(ctx context.Context) error {
+	return l.sinks.flush(ctx)
+}
+
+// Shutdown 停止接收异步日志，等待缓冲中的日志输出完成后关闭所有输出目标
+// ctx 超时后立即返回，未输出的日志会丢失；之后写入的日志同步输出到已关闭的目标，可能失败
+// 模块日志实例共享根实例的输出目标，调用模块实例的 Shutdown 不做任何处理
+func (l *Logger) Shutdown(ctx context.Context) error {
+	if l.module != "" {
+		return nil
+	}
+	return l.sinks.shutdown(ctx)
+}
+
+// Close 关闭所有输出目标，缓冲中的日志会先写出
+func (l *Logger) Close() error {
+	return l.Shutdown(context.Background())
+}
+
+// Dropped 返回异步缓冲区已满时丢弃的日志条数
+func (l *Logger) Dropped() uint64 {
+	if l.sinks.async == nil {
+		return 0
+	}
+	return l.sinks.async.dropped.Load()
+}
+
+// defaultLogger 默认日志实例，未初始化时输出到控制台
+var defaultLogger atomic.Pointer[Logger]
+
+func init() {
+	stdout, _ := newSink(&Config{}, &SinkConfig{Type: SinkStdout})
+	defaultLogger.Store(newLogger(&dispatcher{sinks: []*sink{stdout}}, "info", ""))
+}
+
+// Default 返回默认日志实例
+func Default() *Logger {
+	return defaultLogger.Load()
+}
+
+// SetDefault 替换默认日志实例，包级函数会委托给新的实例
+// 不会关闭被替换的实例，调用方需要在不再使用时调用其 Shutdown 或 Close，否则文件句柄、后台协程和缓冲中的日志会泄漏
+func SetDefault(l *Logger) {
+	if l != nil {
+		defaultLogger.Store(l)
+	}
+}
+
+// replacedShutdownTimeout InitLogger 关闭被替换的实例时等待缓冲日志写出的最长时间
+const replacedShutdownTimeout = 5 * time.Second
+
+// InitLogger 初始化日志配置，并将创建的实例设置为默认日志实例
+// 可以多次调用，每次调用都会替换默认日志实例，并关闭被替换的实例；
+// 从旧实例获取的模块日志实例不再输出到其文件等目标
+func InitLogger(config *Config) {
+	l, err := New(config)
+	if err != nil {
+		logrus.Fatal("Failed to create logger:", err)
+	}
+
+	previous := defaultLogger.Swap(l)
+	if previous == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), replacedShutdownTimeout)
+	defer cancel()
+	if err := previous.Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "logger: failed to shut down replaced logger: %v\n", err)
 	}
 }
 
 // Debug 输出调试日志
 func Debug(args ...interface{}) {
-	mu.RLock()
-	defer mu.RUnlock()
-	if log != nil {
-		log.Debug(args...)
-	}
+	Default().Debug(args...)
 }
 
 // Info 输出信息日志
 func Info(args ...interface{}) {
-	mu.RLock()
-	defer mu.RUnlock()
-	if log != nil {
-		log.Info(args...)
-	}
+	Default().Info(args...)
 }
 
 // Warn 输出警告日志
 func Warn(args ...interface{}) {
-	mu.RLock()
-	defer mu.RUnlock()
-	if log != nil {
-		log.Warn(args...)
-	}
+	Default().Warn(args...)
 }
 
 // Error 输出错误日志
 func Error(args ...interface{}) {
-	mu.RLock()
-	defer mu.RUnlock()
-	if log != nil {
-		log.Error(args...)
-	}
+	Default().Error(args...)
 }
 
 // Fatal 输出致命错误日志
 func Fatal(args ...interface{}) {
-	mu.RLock()
-	defer mu.RUnlock()
-	if log != nil {
-		log.Fatal(args...)
-	}
+	Default().Fatal(args...)
+}
+
+// WithField 添加单个字段到日志
+func WithField(key string, value interface{}) *logrus.Entry {
+	return Default().WithField(key, value)
 }
 
 // WithFields 添加字段到日志
-func WithFields(fields logrus.Fields) *logrus.Entry {
-	mu.RLock()
-	defer mu.RUnlock()
-	if log != nil {
-		return log.WithFields(fields)
-	}
-	return logrus.NewEntry(logrus.New())
+func WithFields(fields Fields) *logrus.Entry {
+	return Default().WithFields(fields)
+}
+
+// WithError 添加错误字段到日志
+// 仅在 debug 级别下附带错误的调用栈
+func WithError(err error) *logrus.Entry {
+	return Default().WithError(err)
+}
+
+// Flush 等待默认日志实例缓冲中的日志全部输出
+func Flush(ctx context.Context) error {
+	return Default().Flush(ctx)
+}
+
+// Shutdown 关闭默认日志实例，等待缓冲中的日志输出完成
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	logger.Shutdown(ctx)
+func Shutdown(ctx context.Context) error {
+	return Default().Shutdown(ctx)
 }
 
 // DefaultConfig 默认配置
 var DefaultConfig = &Config{
 	LogPath:     "logs",
+	FileName:    "app",
+	Rotation:    RotateDaily,
 	MaxSize:     64,
 	MaxBackups:  10,
 	MaxAge:      30,
@@ -0,0 +1,242 @@
+// Package logtest provides an in-memory capture logger for asserting log output in tests.
+//
+// 每个 Recorder 拥有独立的 Logger 实例，通过 Context 注入请求上下文后，
+// logger.Ctx(ctx) 输出的日志只会被当前测试捕获，可以在并行测试中使用：
+//
+//	func TestHandler(t *testing.T) {
+//		t.Parallel()
+//		rec := logtest.New(t)
+//		ctx := rec.Context(context.Background())
+//
+//		handle(ctx)
+//
+//		rec.AssertContains(logrus.ErrorLevel, "订单创建失败", logger.Fields{"order_id": 42})
+//	}
+package logtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NHYCRaymond/calorie/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Any 作为字段期望值时，仅要求字段存在
+var Any = anyValue{}
+
+// anyValue 匹配任意字段值
+type anyValue struct{}
+
+// String 实现 fmt.Stringer 接口
+func (anyValue) String() string {
+	return "<any>"
+}
+
+// Entry 捕获的日志
+type Entry struct {
+	Level   logrus.Level
+	Message string
+	Fields  logger.Fields
+	Time    time.Time
+}
+
+// Field 获取字段值
+func (e Entry) Field(key string) (interface{}, bool) {
+	v, ok := e.Fields[key]
+	return v, ok
+}
+
+// String 返回便于阅读的日志描述
+func (e Entry) String() string {
+	return fmt.Sprintf("[%s] %q %v", e.Level, e.Message, e.Fields)
+}
+
+// Recorder 捕获日志的测试辅助工具，协程安全
+type Recorder struct {
+	t      testing.TB
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New 创建捕获所有级别日志的 Recorder，测试结束时自动关闭
+func New(t testing.TB) *Recorder {
+	t.Helper()
+
+	l, err := logger.New(&logger.Config{
+		Level: "trace",
+		Sinks: []logger.SinkConfig{{Type: logger.SinkWriter, Writer: io.Discard}},
+	})
+	if err != nil {
+		t.Fatalf("logtest: failed to create logger: %v", err)
+	}
+
+	r := &Recorder{t: t, logger: l}
+	l.Logrus().AddHook(r)
+	t.Cleanup(func() {
+		l.Close()
+	})
+	return r
+}
+
+// Install 创建 Recorder 并替换默认日志实例，测试结束时恢复
+// 会影响包级日志函数，不能在并行测试中使用，并行测试应使用 New 和 Context
+func Install(t testing.TB) *Recorder {
+	t.Helper()
+
+	r := New(t)
+	previous := logger.Default()
+	logger.SetDefault(r.logger)
+	t.Cleanup(func() {
+		logger.SetDefault(previous)
+	})
+	return r
+}
+
+// Logger 返回捕获日志的 Logger 实例
+func (r *Recorder) Logger() *logger.Logger {
+	return r.logger
+}
+
+// Context 返回注入了捕获日志实例的上下文，logger.Ctx(ctx) 会输出到该实例
+func (r *Recorder) Context(ctx context.Context) context.Context {
+	return logger.ContextWithLogger(ctx, r.logger)
+}
+
+// Levels 实现 logrus.Hook 接口
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 实现 logrus.Hook 接口
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	fields := make(logger.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Entry{
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  fields,
+		Time:    entry.Time,
+	})
+	return nil
+}
+
+// Entries 返回已捕获的日志副本
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// Len 返回已捕获的日志条数
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Reset 清空已捕获的日志
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Find 返回匹配的日志
+// message 为空时匹配任意消息；fields 中的字段都需要存在且相等，值为 Any 时只要求字段存在
+func (r *Recorder) Find(level logrus.Level, message string, fields logger.Fields) []Entry {
+	var matched []Entry
+	for _, e := range r.Entries() {
+		if matches(e, level, message, fields) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// Contains 判断是否存在匹配的日志，匹配规则见 Find
+func (r *Recorder) Contains(level logrus.Level, message string, fields logger.Fields) bool {
+	return len(r.Find(level, message, fields)) > 0
+}
+
+// AssertContains 断言存在匹配的日志，匹配规则见 Find
+func (r *Recorder) AssertContains(level logrus.Level, message string, fields logger.Fields) {
+	r.t.Helper()
+	if !r.Contains(level, message, fields) {
+		r.t.Errorf("logtest: no entry matched [%s] %q %v\n%s", level, message, fields, r.dump())
+	}
+}
+
+// AssertNotContains 断言不存在匹配的日志，匹配规则见 Find
+func (r *Recorder) AssertNotContains(level logrus.Level, message string, fields logger.Fields) {
+	r.t.Helper()
+	if matched := r.Find(level, message, fields); len(matched) > 0 {
+		r.t.Errorf("logtest: unexpected entry matched [%s] %q %v: %s", level, message, fields, matched[0])
+	}
+}
+
+// AssertCount 断言已捕获的日志条数
+func (r *Recorder) AssertCount(n int) {
+	r.t.Helper()
+	if got := r.Len(); got != n {
+		r.t.Errorf("logtest: expected %d entries, got %d\n%s", n, got, r.dump())
+	}
+}
+
+// AssertEmpty 断言没有捕获任何日志
+func (r *Recorder) AssertEmpty() {
+	r.t.Helper()
+	r.AssertCount(0)
+}
+
+// dump 输出已捕获的日志，用于断言失败时排查
+func (r *Recorder) dump() string {
+	entries := r.Entries()
+	if len(entries) == 0 {
+		return "captured entries: none"
+	}
+
+	var b strings.Builder
+	b.WriteString("captured entries:")
+	for _, e := range entries {
+		b.WriteString("\n  ")
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+// matches 判断日志是否匹配
+func matches(e Entry, level logrus.Level, message string, fields logger.Fields) bool {
+	if e.Level != level {
+		return false
+	}
+	if message != "" && e.Message != message {
+		return false
+	}
+	for k, want := range fields {
+		got, ok := e.Fields[k]
+		if !ok {
+			return false
+		}
+		if _, any := want.(anyValue); !any && !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
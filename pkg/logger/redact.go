@@ -0,0 +1,308 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 脱敏策略
+const (
+	RedactFull    = "full"    // 整体替换为 ******
+	RedactPartial = "partial" // 保留首尾部分字符，如 138*****678
+	RedactHash    = "hash"    // 替换为 SHA-256 摘要，便于关联同一个值而不暴露原文
+)
+
+// 内置的值匹配模式
+var (
+	// PatternCardNumber 银行卡号，13 到 19 位数字，允许空格或短横线分隔，需通过 Luhn 校验
+	PatternCardNumber = RedactPattern{Name: "card_number", Pattern: `\b\d{4}(?:[ -]?\d{4}){2}[ -]?\d{1,7}\b`, Strategy: RedactPartial, Validate: luhnValid}
+	// PatternPhone 中国大陆手机号
+	PatternPhone = RedactPattern{Name: "phone", Pattern: `\b1[3-9]\d{9}\b`, Strategy: RedactPartial}
+	// PatternEmail 电子邮箱
+	PatternEmail = RedactPattern{Name: "email", Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, Strategy: RedactPartial}
+	// PatternIDCard 中国大陆居民身份证号
+	PatternIDCard = RedactPattern{Name: "id_card", Pattern: `\b\d{17}[\dXx]\b`, Strategy: RedactPartial}
+)
+
+// RedactionConfig 日志脱敏配置
+// 在日志分发到任何输出目标之前执行，同时作用于消息和字段
+type RedactionConfig struct {
+	// 需要脱敏的字段名，不区分大小写，支持 * 通配符，如 "*password*"、"token"
+	// 字段值整体按 Strategy 脱敏，嵌套的 map 字段同样生效
+	Fields []string
+	// 需要脱敏的值模式，作用于消息和除 ExemptFields 外的所有字符串字段
+	Patterns []RedactPattern
+	// 不按值模式脱敏的字段名，规则同 Fields，用于 request_id、时间戳等本身由数字组成的标识字段
+	// 为 nil 时使用 DefaultExemptFields
+	ExemptFields []string
+	// 字段名匹配时使用的脱敏策略，默认 RedactFull
+	Strategy string
+	// 哈希策略使用的盐值
+	HashSalt string
+}
+
+// RedactPattern 值匹配模式
+type RedactPattern struct {
+	Name     string // 模式名称
+	Pattern  string // 正则表达式
+	Strategy string // 脱敏策略，默认 RedactFull
+	// 校验函数，不为 nil 时只脱敏校验通过的匹配，用于排除格式相同的非敏感值
+	Validate func(match string) bool
+}
+
+// DefaultExemptFields 默认不按值模式脱敏的字段
+var DefaultExemptFields = []string{"request_id", "trace_id", "span_id", "parent_span_id", "time", "timestamp"}
+
+// DefaultRedactionConfig 默认脱敏配置，覆盖常见的密码、令牌字段以及卡号、手机号、邮箱、身份证号
+var DefaultRedactionConfig = &RedactionConfig{
+	Fields: []string{
+		"*password*", "*passwd*", "*secret*", "*token*",
+		"authorization", "cookie", "set-cookie", "*api_key*", "*apikey*",
+		"id_card", "id_number",
+	},
+	Patterns: []RedactPattern{PatternCardNumber, PatternIDCard, PatternPhone, PatternEmail},
+	Strategy: RedactFull,
+}
+
+// redactedText 整体替换后的文本
+const redactedText = "******"
+
+// compiledPattern 编译后的值匹配模式
+type compiledPattern struct {
+	re       *regexp.Regexp
+	strategy string
+	validate func(string) bool
+}
+
+// redactor 日志脱敏器
+type redactor struct {
+	fields   []string
+	exempt   []string
+	patterns []compiledPattern
+	strategy string
+	salt     string
+}
+
+// newRedactor 根据配置创建脱敏器
+func newRedactor(config *RedactionConfig) (*redactor, error) {
+	r := &redactor{
+		strategy: config.Strategy,
+		salt:     config.HashSalt,
+	}
+	if r.strategy == "" {
+		r.strategy = RedactFull
+	}
+	if err := checkStrategy(r.strategy); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if r.fields, err = compileFieldPatterns(config.Fields); err != nil {
+		return nil, err
+	}
+	exempt := config.ExemptFields
+	if exempt == nil {
+		exempt = DefaultExemptFields
+	}
+	if r.exempt, err = compileFieldPatterns(exempt); err != nil {
+		return nil, err
+	}
+
+	for _, p := range config.Patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction: invalid pattern %s: %w", p.Name, err)
+		}
+		strategy := p.Strategy
+		if strategy == "" {
+			strategy = RedactFull
+		}
+		if err := checkStrategy(strategy); err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, compiledPattern{re: re, strategy: strategy, validate: p.Validate})
+	}
+	return r, nil
+}
+
+// compileFieldPatterns 校验字段名模式并转换为小写
+func compileFieldPatterns(fields []string) ([]string, error) {
+	patterns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		pattern := strings.ToLower(field)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("redaction: invalid field pattern %q: %w", field, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// checkStrategy 校验脱敏策略
+func checkStrategy(strategy string) error {
+	switch strategy {
+	case RedactFull, RedactPartial, RedactHash:
+		return nil
+	default:
+		return fmt.Errorf("redaction: unknown strategy %q", strategy)
+	}
+}
+
+// redactEntry 脱敏日志消息和字段，直接修改 entry
+// logrus 在触发 Hook 前已复制字段，修改不会影响调用方的字段
+func (r *redactor) redactEntry(entry *logrus.Entry) {
+	entry.Message = r.redactString(entry.Message)
+	for k, v := range entry.Data {
+		entry.Data[k] = r.redactValue(k, v)
+	}
+}
+
+// redactValue 脱敏单个字段
+func (r *redactor) redactValue(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if r.matchField(key) {
+		return r.mask(fmt.Sprint(value), r.strategy)
+	}
+	if matchFieldPattern(r.exempt, key) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+	case error:
+		if s := v.Error(); len(r.patterns) > 0 {
+			// 错误包含敏感信息时替换为脱敏后的字符串
+			if redacted := r.redactString(s); redacted != s {
+				return redacted
+			}
+		}
+		return v
+	case map[string]interface{}:
+		return r.redactMap(v)
+	case Fields:
+		return Fields(r.redactMap(v))
+	case map[string]string:
+		m := make(map[string]string, len(v))
+		for k, s := range v {
+			switch {
+			case r.matchField(k):
+				m[k] = r.mask(s, r.strategy)
+			case matchFieldPattern(r.exempt, k):
+				m[k] = s
+			default:
+				m[k] = r.redactString(s)
+			}
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+// redactMap 脱敏嵌套字段，返回新的 map
+func (r *redactor) redactMap(m map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		redacted[k] = r.redactValue(k, v)
+	}
+	return redacted
+}
+
+// matchField 判断字段名是否需要脱敏
+func (r *redactor) matchField(key string) bool {
+	return matchFieldPattern(r.fields, key)
+}
+
+// matchFieldPattern 判断字段名是否匹配任一模式
+func matchFieldPattern(patterns []string, key string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString 按值匹配模式脱敏字符串
+func (r *redactor) redactString(s string) string {
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.validate != nil && !p.validate(match) {
+				return match
+			}
+			return r.mask(match, p.strategy)
+		})
+	}
+	return s
+}
+
+// mask 按策略脱敏
+func (r *redactor) mask(s, strategy string) string {
+	switch strategy {
+	case RedactPartial:
+		return maskPartial(s)
+	case RedactHash:
+		sum := sha256.Sum256([]byte(r.salt + s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return redactedText
+	}
+}
+
+// maskPartial 保留首尾部分字符，中间替换为 *
+// 邮箱保留用户名首字符和完整域名
+func maskPartial(s string) string {
+	if at := strings.LastIndexByte(s, '@'); at > 0 {
+		local := []rune(s[:at])
+		return string(local[0]) + strings.Repeat("*", len(local)-1) + s[at:]
+	}
+
+	runes := []rune(s)
+	n := len(runes)
+	if n <= 4 {
+		return strings.Repeat("*", n)
+	}
+
+	keep := n / 3
+	if keep > 4 {
+		keep = 4
+	}
+	return string(runes[:keep]) + strings.Repeat("*", n-2*keep) + string(runes[n-keep:])
+}
+
+// luhnValid 判断卡号是否通过 Luhn 校验，忽略空格和短横线
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,403 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志轮转周期
+const (
+	RotateDaily  = "daily"  // 按天轮转，文件名如 app-2024-01-02.log
+	RotateHourly = "hourly" // 按小时轮转，文件名如 app-2024-01-02-15.log
+)
+
+// 日志文件后缀
+const (
+	logSuffix        = ".log"
+	compressedSuffix = ".gz"
+)
+
+// RotateConfig 日志轮转配置
+type RotateConfig struct {
+	Dir        string // 日志目录
+	FileName   string // 文件名前缀，默认 app
+	Rotation   string // 轮转周期，RotateDaily 或 RotateHourly，默认按天
+	MaxSize    int    // 单个日志文件最大大小（MB），超过后在同一周期内按序号切分，0 表示不限制
+	MaxBackups int    // 保留的旧日志文件最大数量，0 表示不限制
+	MaxAge     int    // 保留的旧日志文件最大天数，0 表示不限制
+	Compress   bool   // 是否使用 gzip 压缩旧日志文件
+}
+
+// RotatingWriter 按时间和大小轮转的日志文件
+// 文件名为 <前缀>-<周期>.log，同一周期内超过大小限制时依次写入 <前缀>-<周期>.1.log、.2.log ……
+// 轮转后在后台压缩旧文件并按数量和天数清理
+type RotatingWriter struct {
+	config *RotateConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period string // 当前文件所属周期
+	index  int    // 当前文件在周期内的序号
+	active string // 最近写入的文件路径，关闭后仍保留，清理时跳过
+
+	millCh   chan struct{}
+	millOnce sync.Once
+	millDone chan struct{}
+
+	now func() time.Time
+}
+
+// NewRotatingWriter 创建轮转日志文件
+func NewRotatingWriter(config *RotateConfig) (*RotatingWriter, error) {
+	cfg := *config
+	if cfg.FileName == "" {
+		cfg.FileName = "app"
+	}
+	if cfg.Rotation == "" {
+		cfg.Rotation = RotateDaily
+	}
+	if cfg.Rotation != RotateDaily && cfg.Rotation != RotateHourly {
+		return nil, fmt.Errorf("unknown log rotation: %q", cfg.Rotation)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &RotatingWriter{
+		config: &cfg,
+		now:    time.Now,
+	}, nil
+}
+
+// Write 实现 io.Writer 接口
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	period := w.periodOf(w.now())
+	if w.file == nil || period != w.period {
+		if err := w.openPeriod(period); err != nil {
+			return 0, err
+		}
+	} else if max := w.maxSize(); max > 0 && w.size > 0 && w.size+int64(len(p)) > max {
+		if err := w.openFile(period, w.index+1); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Filename 返回当前写入的文件路径，尚未写入时返回空字符串
+func (w *RotatingWriter) Filename() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return ""
+	}
+	return w.file.Name()
+}
+
+// Rotate 立即切换到新的日志文件
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	period := w.periodOf(w.now())
+	if w.file == nil || period != w.period {
+		return w.openPeriod(period)
+	}
+	return w.openFile(period, w.index+1)
+}
+
+// Close 关闭当前日志文件并等待后台清理结束
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	millCh, millDone := w.millCh, w.millDone
+	w.millCh = nil
+	err := w.closeFile()
+	w.mu.Unlock()
+
+	if millCh != nil {
+		close(millCh)
+		<-millDone
+	}
+	return err
+}
+
+// periodOf 获取时间所属的轮转周期
+func (w *RotatingWriter) periodOf(t time.Time) string {
+	if w.config.Rotation == RotateHourly {
+		return t.Format("2006-01-02-15")
+	}
+	return t.Format("2006-01-02")
+}
+
+// maxSize 单个文件最大字节数
+func (w *RotatingWriter) maxSize() int64 {
+	return int64(w.config.MaxSize) * 1024 * 1024
+}
+
+// openPeriod 打开周期内最后一个文件，进程重启后继续追加写入
+// 最后一个文件已被压缩时使用下一个序号
+func (w *RotatingWriter) openPeriod(period string) error {
+	index, compressed := -1, false
+	for _, f := range w.listFiles() {
+		if f.period != period {
+			continue
+		}
+		if f.index > index {
+			index, compressed = f.index, f.compressed
+		} else if f.index == index {
+			compressed = compressed || f.compressed
+		}
+	}
+
+	switch {
+	case index < 0:
+		index = 0
+	case compressed:
+		index++
+	}
+	return w.openFile(period, index)
+}
+
+// openFile 关闭当前文件并打开指定周期和序号的文件，调用方需持有锁
+func (w *RotatingWriter) openFile(period string, index int) error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	name := w.filename(period, index)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.period = period
+	w.index = index
+	w.active = name
+	w.mill()
+	return nil
+}
+
+// closeFile 关闭当前文件，调用方需持有锁
+func (w *RotatingWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// filename 生成日志文件路径
+func (w *RotatingWriter) filename(period string, index int) string {
+	name := w.config.FileName + "-" + period
+	if index > 0 {
+		name += "." + strconv.Itoa(index)
+	}
+	return filepath.Join(w.config.Dir, name+logSuffix)
+}
+
+// rotatedFile 目录中的日志文件
+type rotatedFile struct {
+	path       string
+	period     string
+	index      int
+	compressed bool
+	modTime    time.Time
+}
+
+// listFiles 列出目录中属于本日志的文件
+func (w *RotatingWriter) listFiles() []rotatedFile {
+	entries, err := os.ReadDir(w.config.Dir)
+	if err != nil {
+		return nil
+	}
+
+	prefix := w.config.FileName + "-"
+	var files []rotatedFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		f, ok := parseRotatedName(entry.Name(), prefix)
+		if !ok {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			f.modTime = info.ModTime()
+		}
+		f.path = filepath.Join(w.config.Dir, entry.Name())
+		files = append(files, f)
+	}
+	return files
+}
+
+// parseRotatedName 解析日志文件名，格式为 <前缀><周期>[.<序号>].log[.gz]
+func parseRotatedName(name, prefix string) (rotatedFile, bool) {
+	var f rotatedFile
+	if !strings.HasPrefix(name, prefix) {
+		return f, false
+	}
+	name = strings.TrimPrefix(name, prefix)
+	if strings.HasSuffix(name, compressedSuffix) {
+		f.compressed = true
+		name = strings.TrimSuffix(name, compressedSuffix)
+	}
+	if !strings.HasSuffix(name, logSuffix) {
+		return f, false
+	}
+	name = strings.TrimSuffix(name, logSuffix)
+
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		index, err := strconv.Atoi(name[i+1:])
+		if err != nil || index <= 0 {
+			return f, false
+		}
+		f.index = index
+		name = name[:i]
+	}
+	if _, err := time.Parse("2006-01-02", name); err != nil {
+		if _, err := time.Parse("2006-01-02-15", name); err != nil {
+			return f, false
+		}
+	}
+	f.period = name
+	return f, true
+}
+
+// mill 通知后台压缩和清理旧文件，调用方需持有锁
+func (w *RotatingWriter) mill() {
+	if !w.config.Compress && w.config.MaxBackups <= 0 && w.config.MaxAge <= 0 {
+		return
+	}
+
+	w.millOnce.Do(func() {
+		w.millCh = make(chan struct{}, 1)
+		w.millDone = make(chan struct{})
+		go w.millRun(w.millCh, w.millDone)
+	})
+	if w.millCh == nil {
+		return
+	}
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// millRun 后台压缩和清理旧文件
+func (w *RotatingWriter) millRun(ch <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for range ch {
+		w.cleanup()
+	}
+}
+
+// cleanup 压缩和清理旧文件
+// 当前文件和文件列表在同一把锁内获取，处理每个文件前再次确认它没有在之后的轮转中成为当前文件
+func (w *RotatingWriter) cleanup() {
+	w.mu.Lock()
+	current := w.active
+	files := w.listFiles()
+	w.mu.Unlock()
+
+	var backups []rotatedFile
+	for _, f := range files {
+		if f.path != current {
+			backups = append(backups, f)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	var cutoff time.Time
+	if w.config.MaxAge > 0 {
+		cutoff = w.now().Add(-time.Duration(w.config.MaxAge) * 24 * time.Hour)
+	}
+
+	for i, f := range backups {
+		if w.isActive(f.path) {
+			continue
+		}
+		expired := !cutoff.IsZero() && f.modTime.Before(cutoff)
+		if expired || (w.config.MaxBackups > 0 && i >= w.config.MaxBackups) {
+			os.Remove(f.path)
+			continue
+		}
+		if w.config.Compress && !f.compressed {
+			if err := compressFile(f.path); err != nil {
+				fmt.Fprintf(os.Stderr, "logger: failed to compress %s: %v\n", f.path, err)
+			}
+		}
+	}
+}
+
+// isActive 判断文件是否为当前正在写入的文件
+func (w *RotatingWriter) isActive(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.active == path
+}
+
+// compressFile 使用 gzip 压缩文件，成功后删除原文件
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstPath := path + compressedSuffix
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(dstPath)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	os.Chtimes(dstPath, info.ModTime(), info.ModTime())
+	return os.Remove(path)
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sampledReportMessage 丢弃统计日志的消息，该日志本身不参与采样
+const sampledReportMessage = "日志采样丢弃统计"
+
+// DefaultSamplingKeyFields 默认区分相同日志的字段，对应 gin 访问日志的 method、path、status，
+// 访问日志的消息都相同，不区分时所有接口共用一份配额
+var DefaultSamplingKeyFields = []string{"method", "path", "status"}
+
+// SamplingConfig 日志采样配置
+// 每个统计周期内，相同的日志先输出 First 条，之后每 Thereafter 条输出 1 条
+// 相同的日志指级别、消息以及 KeyFields 中字段的值都相同，fatal 和 panic 级别不参与采样
+type SamplingConfig struct {
+	Interval   time.Duration // 统计周期，默认 1 秒
+	First      int           // 每个周期内先输出的条数，不大于 0 时不采样
+	Thereafter int           // 之后每 Thereafter 条输出 1 条，不大于 0 时全部丢弃
+
+	// 按级别覆盖采样规则，键为级别名称，如 "error"
+	Levels map[string]SamplingRule
+
+	// 区分相同日志的字段，使不同接口和状态码分别采样
+	// 为 nil 时使用 DefaultSamplingKeyFields，设置为空切片时只按级别和消息区分
+	KeyFields []string
+
+	// 输出丢弃条数统计的周期，默认 1 分钟
+	ReportInterval time.Duration
+}
+
+// SamplingRule 采样规则
+type SamplingRule struct {
+	First      int // 每个周期内先输出的条数，不大于 0 时该级别不采样
+	Thereafter int // 之后每 Thereafter 条输出 1 条，不大于 0 时全部丢弃
+}
+
+// sampleKey 采样统计的键
+type sampleKey struct {
+	level   logrus.Level
+	message string
+	fields  string
+}
+
+// sampler 日志采样器
+type sampler struct {
+	interval       time.Duration
+	reportInterval time.Duration
+	rules          map[logrus.Level]SamplingRule
+	keyFields      []string
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[sampleKey]int
+	suppressed  map[sampleKey]int
+
+	stopCh chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// newSampler 根据配置创建采样器
+func newSampler(config *SamplingConfig) (*sampler, error) {
+	s := &sampler{
+		interval:       config.Interval,
+		reportInterval: config.ReportInterval,
+		rules:          make(map[logrus.Level]SamplingRule),
+		keyFields:      config.KeyFields,
+		counts:         make(map[sampleKey]int),
+		suppressed:     make(map[sampleKey]int),
+	}
+	if s.keyFields == nil {
+		s.keyFields = DefaultSamplingKeyFields
+	}
+	if s.interval <= 0 {
+		s.interval = time.Second
+	}
+	if s.reportInterval <= 0 {
+		s.reportInterval = time.Minute
+	}
+
+	for _, level := range logrus.AllLevels {
+		if level > logrus.FatalLevel {
+			s.rules[level] = SamplingRule{First: config.First, Thereafter: config.Thereafter}
+		}
+	}
+	for name, rule := range config.Levels {
+		level, err := logrus.ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("sampling: %w", err)
+		}
+		if level > logrus.FatalLevel {
+			s.rules[level] = rule
+		}
+	}
+	return s, nil
+}
+
+// allow 判断日志是否输出，不输出时计入丢弃统计
+func (s *sampler) allow(entry *logrus.Entry) bool {
+	rule, ok := s.rules[entry.Level]
+	if !ok || rule.First <= 0 || entry.Message == sampledReportMessage {
+		return true
+	}
+
+	key := sampleKey{level: entry.Level, message: entry.Message, fields: s.fieldsKey(entry)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 异步输出或并发写入时日志时间可能略早于当前周期的起点，计入当前周期，不重置统计
+	if now := entry.Time; now.Sub(s.windowStart) >= s.interval {
+		s.windowStart = now
+		s.counts = make(map[sampleKey]int)
+	}
+
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= rule.First || (rule.Thereafter > 0 && (n-rule.First)%rule.Thereafter == 0) {
+		return true
+	}
+	s.suppressed[key]++
+	return false
+}
+
+// fieldsKey 拼接区分相同日志的字段值
+func (s *sampler) fieldsKey(entry *logrus.Entry) string {
+	if len(s.keyFields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, field := range s.keyFields {
+		if v, ok := entry.Data[field]; ok {
+			fmt.Fprint(&b, v)
+		}
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// start 启动后台协程，定期通过 entry 输出丢弃条数统计
+func (s *sampler) start(entry *logrus.Entry) {
+	s.stopCh = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.reportInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.report(entry)
+			case <-s.stopCh:
+				s.report(entry)
+				return
+			}
+		}
+	}()
+}
+
+// stop 停止后台协程，并输出剩余的丢弃统计
+func (s *sampler) stop() {
+	if s.stopCh == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.done
+}
+
+// report 输出丢弃条数统计并清零
+func (s *sampler) report(entry *logrus.Entry) {
+	s.mu.Lock()
+	suppressed := s.suppressed
+	s.suppressed = make(map[sampleKey]int)
+	s.mu.Unlock()
+
+	for key, n := range suppressed {
+		fields := Fields{
+			"sampled_level":   key.level.String(),
+			"sampled_message": key.message,
+			"suppressed":      n,
+		}
+		if key.fields != "" {
+			values := strings.Split(key.fields, "\x00")
+			for i, field := range s.keyFields {
+				if i < len(values) && values[i] != "" {
+					fields["sampled_"+field] = values[i]
+				}
+			}
+		}
+		entry.WithFields(fields).Warn(sampledReportMessage)
+	}
+}
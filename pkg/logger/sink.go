@@ -0,0 +1,342 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 日志输出目标类型
+const (
+	SinkStdout = "stdout" // 标准输出
+	SinkStderr = "stderr" // 标准错误输出
+	SinkFile   = "file"   // 按时间和大小轮转的日志文件
+	SinkSyslog = "syslog" // syslog，支持本地 Unix 套接字、UDP 和 TCP
+	SinkHTTP   = "http"   // 批量推送到 HTTP 接口
+	SinkWriter = "writer" // 自定义 io.Writer
+)
+
+// 日志格式
+const (
+	FormatJSON = "json" // JSON 格式
+	FormatText = "text" // 便于阅读的文本格式
+)
+
+// SinkConfig 日志输出目标配置
+type SinkConfig struct {
+	Type   string // 输出目标类型，见 SinkStdout 等常量
+	Level  string // 最低输出级别，为空时输出所有通过 Logger 级别过滤的日志
+	Format string // 日志格式，FormatJSON 或 FormatText，默认 JSON
+
+	// 自定义输出，Type 为 SinkWriter 时使用，写入已加锁；实现 io.Closer 时随 Logger 一起关闭
+	Writer io.Writer
+
+	// 文件，轮转参数使用 Config 中的配置
+	FileName string // 文件名前缀，默认使用 Config.FileName，如错误日志可以设置为 error
+
+	// syslog
+	Network string // 网络类型，udp、tcp、unix、unixgram，为空时连接本地 syslog
+	Address string // 服务地址，如 localhost:514 或 /dev/log
+	Tag     string // syslog 标签，默认使用服务名称
+
+	// HTTP
+	URL           string            // 推送地址
+	Protocol      string            // 推送协议，HTTPProtocolNDJSON、HTTPProtocolLoki 或 HTTPProtocolElasticsearch
+	Headers       map[string]string // 请求头，如鉴权信息
+	Labels        map[string]string // Loki 标签，默认包含服务名称
+	BatchSize     int               // 每批最大条数，默认 100
+	FlushInterval time.Duration     // 最长推送间隔，默认 1 秒
+	BufferSize    int               // 缓冲区最大条数，超过后丢弃新日志，默认 10000
+	Timeout       time.Duration     // 请求超时时间，默认 5 秒
+}
+
+// sinkWriter 日志输出目标
+type sinkWriter interface {
+	// write 写入一条格式化后的日志
+	write(entry *logrus.Entry, line []byte) error
+	// Close 关闭输出目标，缓冲中的日志会先写出
+	Close() error
+}
+
+// sink 带级别和格式的日志输出目标
+type sink struct {
+	name      string
+	level     logrus.Level
+	formatter logrus.Formatter
+	writer    sinkWriter
+}
+
+// dispatcher 将日志分发到各输出目标，作为 logrus Hook 注册
+// 模块日志实例共享同一个 dispatcher
+type dispatcher struct {
+	sinks    []*sink
+	sampler  *sampler       // 日志采样，为 nil 时不采样
+	redactor *redactor      // 日志脱敏，为 nil 时不脱敏
+	async    *asyncPipeline // 异步输出，为 nil 时同步输出
+}
+
+// flusher 带缓冲的输出目标
+type flusher interface {
+	// flush 立即写出缓冲中的日志
+	flush()
+}
+
+// Levels 实现 logrus.Hook 接口
+func (d *dispatcher) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 实现 logrus.Hook 接口
+func (d *dispatcher) Fire(entry *logrus.Entry) error {
+	if d.sampler != nil && !d.sampler.allow(entry) {
+		return nil
+	}
+
+	// fatal 和 panic 之后进程会退出，需要先写完缓冲区并同步输出
+	if entry.Level <= logrus.FatalLevel {
+		if d.async != nil {
+			d.async.flush(context.Background())
+		}
+		err := d.write(entry)
+		d.flushSinks()
+		return err
+	}
+
+	if d.async != nil {
+		d.async.enqueue(snapshot(entry))
+		return nil
+	}
+	return d.write(entry)
+}
+
+// snapshot 复制日志条目，logrus 在 Hook 返回后会继续使用原条目
+func snapshot(entry *logrus.Entry) *logrus.Entry {
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	return &logrus.Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Caller:  entry.Caller,
+		Message: entry.Message,
+		Context: entry.Context,
+	}
+}
+
+// write 脱敏并输出到各目标
+func (d *dispatcher) write(entry *logrus.Entry) error {
+	if d.redactor != nil {
+		d.redactor.redactEntry(entry)
+	}
+
+	var firstErr error
+	for _, s := range d.sinks {
+		if entry.Level > s.level {
+			continue
+		}
+		line, err := s.formatter.Format(entry)
+		if err == nil {
+			err = s.writer.write(entry, line)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("sink %s: %w", s.name, err)
+		}
+	}
+	return firstErr
+}
+
+// writeAsync 后台协程输出日志，错误输出到标准错误
+func (d *dispatcher) writeAsync(entry *logrus.Entry) {
+	if err := d.write(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write log: %v\n", err)
+	}
+}
+
+// flush 等待异步缓冲区写完，并写出各目标缓冲中的日志
+func (d *dispatcher) flush(ctx context.Context) error {
+	if d.async != nil {
+		if err := d.async.flush(ctx); err != nil {
+			return err
+		}
+	}
+	d.flushSinks()
+	return nil
+}
+
+// flushSinks 写出各目标缓冲中的日志
+func (d *dispatcher) flushSinks() {
+	for _, s := range d.sinks {
+		if f, ok := s.writer.(flusher); ok {
+			f.flush()
+		}
+	}
+}
+
+// shutdown 停止采样统计和异步输出，写完缓冲区后关闭所有输出目标
+func (d *dispatcher) shutdown(ctx context.Context) error {
+	if d.sampler != nil {
+		d.sampler.stop()
+	}
+
+	var firstErr error
+	if d.async != nil {
+		firstErr = d.async.close(ctx)
+	}
+	for _, s := range d.sinks {
+		if err := s.writer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close 关闭所有输出目标
+func (d *dispatcher) Close() error {
+	return d.shutdown(context.Background())
+}
+
+// discardFormatter 不输出任何内容，实际输出由 dispatcher 完成
+type discardFormatter struct{}
+
+// Format 实现 logrus.Formatter 接口
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+// newDispatcher 根据配置创建所有输出目标，任一目标创建失败时关闭已创建的目标
+func newDispatcher(config *Config, sinks []SinkConfig) (*dispatcher, error) {
+	d := &dispatcher{}
+	for i := range sinks {
+		s, err := newSink(config, &sinks[i])
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("logger sink %d (%s): %w", i, sinks[i].Type, err)
+		}
+		d.sinks = append(d.sinks, s)
+	}
+	return d, nil
+}
+
+// newSink 创建日志输出目标
+func newSink(config *Config, sc *SinkConfig) (*sink, error) {
+	level := logrus.TraceLevel
+	if sc.Level != "" {
+		lvl, err := logrus.ParseLevel(sc.Level)
+		if err != nil {
+			return nil, err
+		}
+		level = lvl
+	}
+
+	formatter, err := newFormatter(sc.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var w sinkWriter
+	switch sc.Type {
+	case SinkStdout:
+		w = newWriterSink(os.Stdout, nil)
+	case SinkStderr:
+		w = newWriterSink(os.Stderr, nil)
+	case SinkWriter:
+		if sc.Writer == nil {
+			return nil, fmt.Errorf("writer sink requires writer")
+		}
+		closer, _ := sc.Writer.(io.Closer)
+		w = newWriterSink(sc.Writer, closer)
+	case SinkFile:
+		fileName := sc.FileName
+		if fileName == "" {
+			fileName = config.FileName
+		}
+		file, err := NewRotatingWriter(&RotateConfig{
+			Dir:        config.LogPath,
+			FileName:   fileName,
+			Rotation:   config.Rotation,
+			MaxSize:    config.MaxSize,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAge,
+			Compress:   config.Compress,
+		})
+		if err != nil {
+			return nil, err
+		}
+		w = newWriterSink(file, file)
+	case SinkSyslog:
+		tag := sc.Tag
+		if tag == "" {
+			tag = config.ServiceName
+		}
+		w, err = newSyslogSink(sc.Network, sc.Address, tag)
+	case SinkHTTP:
+		w, err = newHTTPSink(sc, config.ServiceName)
+	default:
+		err = fmt.Errorf("unknown sink type: %q", sc.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sink{
+		name:      sc.Type,
+		level:     level,
+		formatter: formatter,
+		writer:    w,
+	}, nil
+}
+
+// newFormatter 创建日志格式化器
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", FormatJSON:
+		return &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339,
+		}, nil
+	case FormatText:
+		return &logrus.TextFormatter{
+			FullTimestamp:   true,
+			TimestampFormat: time.RFC3339,
+			DisableColors:   true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %q", format)
+	}
+}
+
+// writerSink 输出到 io.Writer
+type writerSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// newWriterSink 创建输出到 io.Writer 的目标，closer 为 nil 时关闭不做任何处理
+func newWriterSink(w io.Writer, closer io.Closer) *writerSink {
+	return &writerSink{w: w, closer: closer}
+}
+
+// write 写入一条日志
+func (s *writerSink) write(_ *logrus.Entry, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(line)
+	return err
+}
+
+// Close 关闭输出目标
+func (s *writerSink) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HTTP 推送协议
+const (
+	// HTTPProtocolNDJSON 每行一条日志，适用于 Vector、Fluent Bit 等采集器
+	HTTPProtocolNDJSON = "ndjson"
+	// HTTPProtocolLoki Loki push API，URL 如 http://loki:3100/loki/api/v1/push
+	HTTPProtocolLoki = "loki"
+	// HTTPProtocolElasticsearch Elasticsearch bulk API，URL 如 http://es:9200/logs/_bulk，日志格式需为 JSON
+	HTTPProtocolElasticsearch = "elasticsearch"
+)
+
+// httpRecord 待推送的日志
+type httpRecord struct {
+	time time.Time
+	line []byte
+}
+
+// httpSink 批量推送日志到 HTTP 接口
+// 日志先写入缓冲区，达到批量大小或推送间隔时由后台协程推送
+type httpSink struct {
+	url       string
+	protocol  string
+	headers   map[string]string
+	labels    map[string]string
+	batchSize int
+	interval  time.Duration
+	capacity  int
+	client    *http.Client
+
+	mu      sync.Mutex
+	buffer  []httpRecord
+	dropped int64
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// newHTTPSink 创建 HTTP 推送目标并启动后台推送
+func newHTTPSink(sc *SinkConfig, serviceName string) (sinkWriter, error) {
+	if sc.URL == "" {
+		return nil, fmt.Errorf("http sink requires url")
+	}
+
+	s := &httpSink{
+		url:       sc.URL,
+		protocol:  sc.Protocol,
+		headers:   sc.Headers,
+		labels:    sc.Labels,
+		batchSize: sc.BatchSize,
+		interval:  sc.FlushInterval,
+		capacity:  sc.BufferSize,
+		client:    &http.Client{Timeout: sc.Timeout},
+		flushCh:   make(chan struct{}, 1),
+		stopCh:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	switch s.protocol {
+	case "":
+		s.protocol = HTTPProtocolNDJSON
+	case HTTPProtocolNDJSON, HTTPProtocolLoki, HTTPProtocolElasticsearch:
+	default:
+		return nil, fmt.Errorf("unknown http sink protocol: %q", sc.Protocol)
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = 100
+	}
+	if s.interval <= 0 {
+		s.interval = time.Second
+	}
+	if s.capacity <= 0 {
+		s.capacity = 10000
+	}
+	if s.client.Timeout <= 0 {
+		s.client.Timeout = 5 * time.Second
+	}
+	if len(s.labels) == 0 {
+		s.labels = map[string]string{"service": serviceName}
+	}
+
+	go s.run()
+	return s, nil
+}
+
+// write 写入缓冲区，缓冲区已满时丢弃
+func (s *httpSink) write(entry *logrus.Entry, line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buffer) >= s.capacity {
+		s.dropped++
+		return nil
+	}
+
+	// 格式化结果可能复用 entry 的缓冲区，需要复制
+	s.buffer = append(s.buffer, httpRecord{
+		time: entry.Time,
+		line: bytes.TrimRight(append([]byte(nil), line...), "\n"),
+	})
+	if len(s.buffer) >= s.batchSize {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close 停止后台推送，并推送缓冲区中剩余的日志
+func (s *httpSink) Close() error {
+	s.once.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.done
+	return nil
+}
+
+// run 后台推送
+func (s *httpSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.flushCh:
+			s.flush()
+		case <-s.stopCh:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush 分批推送缓冲区中的日志
+func (s *httpSink) flush() {
+	for {
+		s.mu.Lock()
+		n := len(s.buffer)
+		if n > s.batchSize {
+			n = s.batchSize
+		}
+		batch := s.buffer[:n:n]
+		s.buffer = s.buffer[n:]
+		dropped := s.dropped
+		s.dropped = 0
+		s.mu.Unlock()
+
+		if dropped > 0 {
+			fmt.Fprintf(os.Stderr, "logger: http sink buffer full, %d entries dropped\n", dropped)
+		}
+		if n == 0 {
+			return
+		}
+		if err := s.send(batch); err != nil {
+			fmt.Fprintf(os.Stderr, "logger: http sink failed to push %d entries: %v\n", n, err)
+		}
+	}
+}
+
+// send 推送一批日志
+func (s *httpSink) send(batch []httpRecord) error {
+	body, contentType, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// encode 按推送协议编码一批日志
+func (s *httpSink) encode(batch []httpRecord) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch s.protocol {
+	case HTTPProtocolLoki:
+		values := make([][2]string, 0, len(batch))
+		for _, r := range batch {
+			values = append(values, [2]string{strconv.FormatInt(r.time.UnixNano(), 10), string(r.line)})
+		}
+		err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+			"streams": []map[string]interface{}{
+				{"stream": s.labels, "values": values},
+			},
+		})
+		return buf.Bytes(), "application/json", err
+	case HTTPProtocolElasticsearch:
+		for _, r := range batch {
+			buf.WriteString("{\"index\":{}}\n")
+			buf.Write(r.line)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	default:
+		for _, r := range batch {
+			buf.Write(r.line)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+}
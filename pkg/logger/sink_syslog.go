@@ -0,0 +1,48 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"bytes"
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogSink 输出到 syslog，日志级别映射为 syslog 严重级别
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// newSyslogSink 连接 syslog，network 为空时连接本地 syslog
+func newSyslogSink(network, address, tag string) (sinkWriter, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+// write 写入一条日志
+func (s *syslogSink) write(entry *logrus.Entry, line []byte) error {
+	msg := string(bytes.TrimRight(line, "\n"))
+	switch entry.Level {
+	case logrus.PanicLevel:
+		return s.w.Emerg(msg)
+	case logrus.FatalLevel:
+		return s.w.Crit(msg)
+	case logrus.ErrorLevel:
+		return s.w.Err(msg)
+	case logrus.WarnLevel:
+		return s.w.Warning(msg)
+	case logrus.InfoLevel:
+		return s.w.Info(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
+
+// Close 关闭 syslog 连接
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
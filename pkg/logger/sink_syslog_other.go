@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package logger
+
+import "errors"
+
+// newSyslogSink 当前平台不支持 syslog
+func newSyslogSink(network, address, tag string) (sinkWriter, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
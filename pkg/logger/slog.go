@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler 将 log/slog 日志输出到 Logger 的 slog.Handler 实现
+// 日志与 logrus 接口共用同一套级别、输出目标、采样、脱敏和异步管道，
+// 并附带上下文中的 request_id、trace_id 等字段；分组映射为嵌套字段
+type SlogHandler struct {
+	logger *Logger
+	fields Fields   // WithAttrs 添加的字段，分组为嵌套的 Fields
+	groups []string // WithGroup 打开的分组路径
+}
+
+// NewSlogHandler 创建 slog.Handler，l 为 nil 时使用默认日志实例
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+// Slog 返回输出到当前日志实例的 *slog.Logger
+//
+//	slog.SetDefault(logger.Default().Slog())
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+// Slog 返回输出到默认日志实例的 *slog.Logger
+// 默认日志实例在调用时确定，之后 SetDefault 不会影响已返回的实例
+func Slog() *slog.Logger {
+	return Default().Slog()
+}
+
+// Enabled 实现 slog.Handler 接口
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.target().IsLevelEnabled(logrusLevel(level))
+}
+
+// Handle 实现 slog.Handler 接口
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := cloneFields(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.groups, a)
+		return true
+	})
+
+	entry := h.target().FromContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+	entry.Log(logrusLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs 实现 slog.Handler 接口
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := cloneFields(h.fields)
+	for _, a := range attrs {
+		addAttr(fields, h.groups, a)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, groups: h.groups}
+}
+
+// WithGroup 实现 slog.Handler 接口
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+	return &SlogHandler{logger: h.logger, fields: h.fields, groups: append(groups, name)}
+}
+
+// target 获取输出的日志实例
+func (h *SlogHandler) target() *Logger {
+	if h.logger == nil {
+		return Default()
+	}
+	return h.logger
+}
+
+// logrusLevel slog 级别转换为 logrus 级别
+// 低于 Debug 的级别映射为 Trace，高于 Error 的级别仍映射为 Error，不会触发退出或 panic
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
+
+// addAttr 将属性添加到 groups 路径下，分组仅在包含属性时创建
+func addAttr(fields Fields, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		// 空键的分组直接展开到当前层级
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			addAttr(fields, groups, ga)
+		}
+		return
+	}
+
+	target := fields
+	for _, g := range groups {
+		next, ok := target[g].(Fields)
+		if !ok {
+			next = make(Fields)
+			target[g] = next
+		}
+		target = next
+	}
+	target[a.Key] = attrValue(a.Value)
+}
+
+// attrValue 获取属性值，错误转换为字符串，以便嵌套在分组中时也能输出
+func attrValue(v slog.Value) interface{} {
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+	return v.Any()
+}
+
+// cloneFields 深拷贝字段，嵌套的 Fields 同样复制
+func cloneFields(fields Fields) Fields {
+	cloned := make(Fields, len(fields))
+	for k, v := range fields {
+		if nested, ok := v.(Fields); ok {
+			v = cloneFields(nested)
+		}
+		cloned[k] = v
+	}
+	return cloned
+}
@@ -13,7 +13,10 @@ import (
 	"errors"
 	"time"
 
+	apperrors "github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
 	"github.com/NHYCRaymond/calorie/pkg/metrics"
+	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -126,14 +129,14 @@ func (c *Client) StartSession(opts ...*options.SessionOptions) (mongo.Session, e
 func (c *Client) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) (interface{}, error), opts ...*options.TransactionOptions) (interface{}, error) {
 	session, err := c.client.StartSession()
 	if err != nil {
-		return nil, err
+		return nil, WrapError(err, "start_session")
 	}
 	defer session.EndSession(ctx)
 
 	// 注意：原版的 session.WithTransaction 自动处理提交和回滚
 	result, err := session.WithTransaction(ctx, fn, opts...)
 	if err != nil {
-		return nil, err // 如果 fn 返回错误或提交失败，会返回错误
+		return nil, WrapError(err, "transaction") // 如果 fn 返回错误或提交失败，会返回错误
 	}
 
 	return result, nil
@@ -143,23 +146,24 @@ func (c *Client) WithTransaction(ctx context.Context, fn func(sessCtx mongo.Sess
 func (c *Client) InsertOne(ctx context.Context, collection string, document interface{}) (*mongo.InsertOneResult, error) {
 	start := time.Now()
 	result, err := c.Collection(collection).InsertOne(ctx, document)
-	c.recordMetrics("insert_one", collection, err, start)
-	return result, err
+	c.recordMetrics(ctx, "insert_one", collection, err, start)
+	return result, WrapError(err, "insert_one")
 }
 
 // InsertMany 插入多个文档
 func (c *Client) InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error) {
 	start := time.Now()
 	result, err := c.Collection(collection).InsertMany(ctx, documents)
-	c.recordMetrics("insert_many", collection, err, start)
-	return result, err
+	c.recordMetrics(ctx, "insert_many", collection, err, start)
+	return result, WrapError(err, "insert_many")
 }
 
 // FindOne 查询单个文档
+// 注意：SingleResult 返回的错误未经包装，可以使用 WrapError 填充错误分类
 func (c *Client) FindOne(ctx context.Context, collection string, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
 	start := time.Now()
 	result := c.Collection(collection).FindOne(ctx, filter, opts...)
-	c.recordMetrics("find_one", collection, result.Err(), start)
+	c.recordMetrics(ctx, "find_one", collection, result.Err(), start)
 	return result
 }
 
@@ -169,60 +173,62 @@ func (c *Client) FindOne(ctx context.Context, collection string, filter interfac
 func (c *Client) Find(ctx context.Context, collection string, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
 	start := time.Now()
 	cursor, err := c.Collection(collection).Find(ctx, filter, opts...)
-	c.recordMetrics("find", collection, err, start)
-	return cursor, err
+	c.recordMetrics(ctx, "find", collection, err, start)
+	return cursor, WrapError(err, "find")
 }
 
 // UpdateOne 更新单个文档
 func (c *Client) UpdateOne(ctx context.Context, collection string, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
 	start := time.Now()
 	result, err := c.Collection(collection).UpdateOne(ctx, filter, update, opts...)
-	c.recordMetrics("update_one", collection, err, start)
-	return result, err
+	c.recordMetrics(ctx, "update_one", collection, err, start)
+	return result, WrapError(err, "update_one")
 }
 
 // UpdateMany 更新多个文档
 func (c *Client) UpdateMany(ctx context.Context, collection string, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
 	start := time.Now()
 	result, err := c.Collection(collection).UpdateMany(ctx, filter, update, opts...)
-	c.recordMetrics("update_many", collection, err, start)
-	return result, err
+	c.recordMetrics(ctx, "update_many", collection, err, start)
+	return result, WrapError(err, "update_many")
 }
 
 // DeleteOne 删除单个文档
 func (c *Client) DeleteOne(ctx context.Context, collection string, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 	start := time.Now()
 	result, err := c.Collection(collection).DeleteOne(ctx, filter, opts...)
-	c.recordMetrics("delete_one", collection, err, start)
-	return result, err
+	c.recordMetrics(ctx, "delete_one", collection, err, start)
+	return result, WrapError(err, "delete_one")
 }
 
 // DeleteMany 删除多个文档
 func (c *Client) DeleteMany(ctx context.Context, collection string, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
 	start := time.Now()
 	result, err := c.Collection(collection).DeleteMany(ctx, filter, opts...)
-	c.recordMetrics("delete_many", collection, err, start)
-	return result, err
+	c.recordMetrics(ctx, "delete_many", collection, err, start)
+	return result, WrapError(err, "delete_many")
 }
 
 // CountDocuments 统计文档数量
 func (c *Client) CountDocuments(ctx context.Context, collection string, filter interface{}, opts ...*options.CountOptions) (int64, error) {
 	start := time.Now()
 	count, err := c.Collection(collection).CountDocuments(ctx, filter, opts...)
-	c.recordMetrics("count_documents", collection, err, start)
-	return count, err
+	c.recordMetrics(ctx, "count_documents", collection, err, start)
+	return count, WrapError(err, "count_documents")
 }
 
 // Aggregate 聚合查询
 func (c *Client) Aggregate(ctx context.Context, collection string, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
 	start := time.Now()
 	cursor, err := c.Collection(collection).Aggregate(ctx, pipeline, opts...)
-	c.recordMetrics("aggregate", collection, err, start)
-	return cursor, err
+	c.recordMetrics(ctx, "aggregate", collection, err, start)
+	return cursor, WrapError(err, "aggregate")
 }
 
-// recordMetrics 记录指标
-func (c *Client) recordMetrics(operation, collection string, err error, start time.Time) {
+// recordMetrics 记录日志和指标
+func (c *Client) recordMetrics(ctx context.Context, operation, collection string, err error, start time.Time) {
+	c.log(ctx, operation, collection, err, start)
+
 	if !c.config.EnableMetrics || c.metrics == nil {
 		return
 	}
@@ -244,6 +250,26 @@ func (c *Client) recordMetrics(operation, collection string, err error, start ti
 	).WithLabelValues(operation, collection, getStatus(err), c.config.ServiceName).Inc()
 }
 
+// log 记录操作日志，日志会附带上下文中的请求ID等字段
+// 仅在 debug 级别下输出，失败时的错误由调用方处理和记录，避免重复输出
+func (c *Client) log(ctx context.Context, operation, collection string, err error, start time.Time) {
+	if !logger.LoggerFromContext(ctx).IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	entry := logger.Ctx(ctx).WithFields(logger.Fields{
+		"client":      c.config.ServiceName,
+		"operation":   operation,
+		"collection":  collection,
+		"duration_ms": float64(time.Since(start).Nanoseconds()) / 1e6,
+	})
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		entry.WithError(err).Debug("MongoDB 操作失败")
+		return
+	}
+	entry.Debug("MongoDB 操作完成")
+}
+
 // getStatus 获取操作状态
 func getStatus(err error) string {
 	if err == nil {
@@ -260,6 +286,53 @@ var (
 	ErrInvalidArgument = errors.New("invalid argument")
 )
 
+// MongoDB 错误码和错误标签
+const (
+	errWriteConflict          = 112                         // 写冲突
+	labelTransientTransaction = "TransientTransactionError" // 可重试的临时事务错误
+)
+
+// WrapError 包装 MongoDB 错误，并根据驱动错误类型填充错误分类
+// 原始错误作为 cause 保留，errors.Is(err, mongo.ErrNoDocuments) 等判断仍然有效
+func WrapError(err error, operation string) error {
+	if err == nil {
+		return nil
+	}
+
+	// 业务函数返回的 *errors.Error 保持原样
+	if _, ok := err.(*apperrors.Error); ok {
+		return err
+	}
+
+	var e *apperrors.Error
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		e = apperrors.Wrap(err, apperrors.CodeNotFound, "document not found").WithKind(apperrors.KindNotFound)
+	case mongo.IsDuplicateKeyError(err):
+		e = apperrors.Wrap(err, apperrors.CodeConflict, "duplicate key").WithKind(apperrors.KindConflict)
+	case isTransientConflict(err):
+		e = apperrors.Wrap(err, apperrors.CodeConflict, "write conflict").
+			WithKind(apperrors.KindConflict).WithRetryable(true)
+	case mongo.IsTimeout(err):
+		e = apperrors.Wrap(err, apperrors.CodeTimeout, "operation timeout").WithKind(apperrors.KindTimeout)
+	case mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		e = apperrors.Wrap(err, apperrors.CodeUnavailable, "connection failed").WithKind(apperrors.KindUnavailable)
+	default:
+		e = apperrors.Wrap(err, apperrors.CodeServerError, "mongodb error")
+	}
+	return e.WithDetails(operation)
+}
+
+// isTransientConflict 判断是否为可重试的写冲突或临时事务错误
+func isTransientConflict(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	return serverErr.HasErrorCode(errWriteConflict) ||
+		serverErr.HasErrorLabel(labelTransientTransaction)
+}
+
 // ConvertID 转换 ID 为 ObjectID
 func ConvertID(id string) (primitive.ObjectID, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
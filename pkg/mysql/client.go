@@ -11,11 +11,18 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"net"
+	"syscall"
 	"time"
 
+	apperrors "github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
 	"github.com/NHYCRaymond/calorie/pkg/metrics"
-	_ "github.com/go-sql-driver/mysql"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
 )
 
 // Config MySQL 配置
@@ -98,7 +105,7 @@ func (c *Client) Close() error {
 func (c *Client) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return WrapError(err, "begin")
 	}
 
 	defer func() {
@@ -109,13 +116,18 @@ func (c *Client) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error)
 	}()
 
 	if err := fn(tx); err != nil {
+		// fn 返回的 *apperrors.Error 原样返回，其他错误按驱动错误分类，死锁等可以重试
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) {
+			err = WrapError(err, "transaction")
+		}
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return errors.New(err.Error() + ": " + rbErr.Error())
+			return fmt.Errorf("%w (rollback: %v)", err, rbErr)
 		}
 		return err
 	}
 
-	return tx.Commit()
+	return WrapError(tx.Commit(), "commit")
 }
 
 // Query 执行查询
@@ -124,15 +136,16 @@ func (c *Client) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error)
 func (c *Client) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	start := time.Now()
 	rows, err := c.db.QueryContext(ctx, query, args...)
-	c.recordMetrics("query", err, start)
-	return rows, err
+	c.recordMetrics(ctx, "query", err, start)
+	return rows, WrapError(err, "query")
 }
 
 // QueryRow 执行单行查询
+// 注意：Row.Scan 返回的错误未经包装，可以使用 WrapError 填充错误分类
 func (c *Client) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	start := time.Now()
 	row := c.db.QueryRowContext(ctx, query, args...)
-	c.recordMetrics("query_row", row.Err(), start)
+	c.recordMetrics(ctx, "query_row", row.Err(), start)
 	return row
 }
 
@@ -140,28 +153,30 @@ func (c *Client) QueryRow(ctx context.Context, query string, args ...interface{}
 func (c *Client) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	start := time.Now()
 	result, err := c.db.ExecContext(ctx, query, args...)
-	c.recordMetrics("exec", err, start)
-	return result, err
+	c.recordMetrics(ctx, "exec", err, start)
+	return result, WrapError(err, "exec")
 }
 
 // Prepare 准备语句
 func (c *Client) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
 	start := time.Now()
 	stmt, err := c.db.PrepareContext(ctx, query)
-	c.recordMetrics("prepare", err, start)
-	return stmt, err
+	c.recordMetrics(ctx, "prepare", err, start)
+	return stmt, WrapError(err, "prepare")
 }
 
 // Begin 开始事务
 func (c *Client) Begin(ctx context.Context) (*sql.Tx, error) {
 	start := time.Now()
 	tx, err := c.db.BeginTx(ctx, nil)
-	c.recordMetrics("begin", err, start)
-	return tx, err
+	c.recordMetrics(ctx, "begin", err, start)
+	return tx, WrapError(err, "begin")
 }
 
-// recordMetrics 记录指标
-func (c *Client) recordMetrics(operation string, err error, start time.Time) {
+// recordMetrics 记录日志和指标
+func (c *Client) recordMetrics(ctx context.Context, operation string, err error, start time.Time) {
+	c.log(ctx, operation, err, start)
+
 	if !c.config.EnableMetrics || c.metrics == nil {
 		return
 	}
@@ -183,6 +198,25 @@ func (c *Client) recordMetrics(operation string, err error, start time.Time) {
 	).WithLabelValues(operation, getStatus(err), c.config.ServiceName).Inc()
 }
 
+// log 记录操作日志，日志会附带上下文中的请求ID等字段
+// 仅在 debug 级别下输出，失败时的错误由调用方处理和记录，避免重复输出
+func (c *Client) log(ctx context.Context, operation string, err error, start time.Time) {
+	if !logger.LoggerFromContext(ctx).IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	entry := logger.Ctx(ctx).WithFields(logger.Fields{
+		"client":      c.config.ServiceName,
+		"operation":   operation,
+		"duration_ms": float64(time.Since(start).Nanoseconds()) / 1e6,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		entry.WithError(err).Debug("MySQL 操作失败")
+		return
+	}
+	entry.Debug("MySQL 操作完成")
+}
+
 // getStatus 获取操作状态
 func getStatus(err error) string {
 	if err == nil {
@@ -198,3 +232,69 @@ var (
 	ErrConnDone        = sql.ErrConnDone
 	ErrInvalidArgument = errors.New("invalid argument")
 )
+
+// MySQL 服务端错误号
+const (
+	errDupEntry            = 1062 // 唯一键冲突
+	errDupEntryWithKey     = 1586 // 唯一键冲突
+	errLockWaitTimeout     = 1205 // 锁等待超时
+	errLockDeadlock        = 1213 // 死锁
+	errConCount            = 1040 // 连接数过多
+	errTooManyUserConns    = 1203 // 用户连接数过多
+	errServerShutdown      = 1053 // 服务正在关闭
+	errQueryTimeout        = 3024 // 超过 max_execution_time
+	errReadOnlyTransaction = 1792 // 只读事务中执行写操作
+)
+
+// WrapError 包装 MySQL 错误，并根据驱动错误类型填充错误分类
+// 原始错误作为 cause 保留，errors.Is(err, sql.ErrNoRows) 等判断仍然有效
+func WrapError(err error, operation string) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *apperrors.Error
+	var myErr *mysqldriver.MySQLError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		e = apperrors.Wrap(err, apperrors.CodeNotFound, "record not found").WithKind(apperrors.KindNotFound)
+	case errors.As(err, &myErr):
+		e = wrapServerError(err, myErr)
+	case apperrors.IsTimeout(err):
+		e = apperrors.Wrap(err, apperrors.CodeTimeout, "operation timeout").WithKind(apperrors.KindTimeout)
+	case isUnavailable(err):
+		e = apperrors.Wrap(err, apperrors.CodeUnavailable, "connection failed").WithKind(apperrors.KindUnavailable)
+	default:
+		e = apperrors.Wrap(err, apperrors.CodeServerError, "mysql error")
+	}
+	return e.WithDetails(operation)
+}
+
+// wrapServerError 根据 MySQL 服务端错误号分类
+func wrapServerError(err error, myErr *mysqldriver.MySQLError) *apperrors.Error {
+	switch myErr.Number {
+	case errDupEntry, errDupEntryWithKey:
+		return apperrors.Wrap(err, apperrors.CodeConflict, "duplicate entry").WithKind(apperrors.KindConflict)
+	case errLockDeadlock:
+		return apperrors.Wrap(err, apperrors.CodeConflict, "deadlock").
+			WithKind(apperrors.KindConflict).WithRetryable(true)
+	case errLockWaitTimeout, errQueryTimeout:
+		return apperrors.Wrap(err, apperrors.CodeTimeout, "operation timeout").WithKind(apperrors.KindTimeout)
+	case errConCount, errTooManyUserConns, errServerShutdown, errReadOnlyTransaction:
+		return apperrors.Wrap(err, apperrors.CodeUnavailable, "server unavailable").WithKind(apperrors.KindUnavailable)
+	default:
+		return apperrors.Wrap(err, apperrors.CodeServerError, "mysql error")
+	}
+}
+
+// isUnavailable 判断是否为连接错误
+func isUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysqldriver.ErrInvalidConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
@@ -10,12 +10,18 @@ package redis
 
 import (
 	"context"
+	stderrors "errors"
+	"io"
+	"net"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/NHYCRaymond/calorie/pkg/errors"
+	"github.com/NHYCRaymond/calorie/pkg/logger"
 	"github.com/NHYCRaymond/calorie/pkg/metrics"
 	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
 )
 
 // Config Redis 配置
@@ -108,42 +114,96 @@ var (
 	// ErrInvalidType 类型错误
 	ErrInvalidType = errors.New(errors.CodeError, "invalid type")
 	// ErrConnectionFailed 连接失败
-	ErrConnectionFailed = errors.New(errors.CodeServerError, "connection failed")
+	ErrConnectionFailed = errors.New(errors.CodeUnavailable, "connection failed")
 	// ErrOperationTimeout 操作超时
-	ErrOperationTimeout = errors.New(errors.CodeServerError, "operation timeout")
+	ErrOperationTimeout = errors.New(errors.CodeTimeout, "operation timeout")
 	// ErrInvalidArgument 参数错误
 	ErrInvalidArgument = errors.New(errors.CodeError, "invalid argument")
 	// ErrRedisError Redis 错误
 	ErrRedisError = errors.New(errors.CodeServerError, "redis error")
 )
 
-// wrapError 包装错误
+// wrapError 包装错误，并根据底层错误类型填充错误分类
 func wrapError(err error, operation string) error {
 	if err == nil {
 		return nil
 	}
 
-	// Redis 原生错误
-	if err == redis.Nil {
-		return errors.New(errors.CodeNotFound, "key not found").WithDetails(operation)
+	var e *errors.Error
+	switch {
+	// 键不存在
+	case err == redis.Nil:
+		e = errors.Wrap(err, errors.CodeNotFound, "key not found").WithKind(errors.KindNotFound)
+	// WATCH 的键被修改导致事务失败，可以重试
+	case err == redis.TxFailedErr:
+		e = errors.Wrap(err, errors.CodeConflict, "transaction failed").
+			WithKind(errors.KindConflict).WithRetryable(true)
+	// 超时错误，包括连接池获取连接超时
+	case errors.IsTimeout(err) || isPoolTimeout(err):
+		e = errors.Wrap(err, errors.CodeTimeout, "operation timeout").WithKind(errors.KindTimeout)
+	// 连接错误或服务端暂时不可用
+	case isUnavailable(err):
+		e = errors.Wrap(err, errors.CodeUnavailable, "connection failed").WithKind(errors.KindUnavailable)
+	// 其他错误
+	default:
+		e = errors.Wrap(err, errors.CodeServerError, "redis error")
+	}
+	return e.WithDetails(operation)
+}
+
+// errPoolTimeout 连接池获取连接超时的错误信息
+// go-redis 的 pool.ErrPoolTimeout 位于 internal 包且没有 Timeout() 方法，只能按错误信息判断
+const errPoolTimeout = "redis: connection pool timeout"
+
+// isPoolTimeout 判断是否为连接池获取连接超时
+func isPoolTimeout(err error) bool {
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		if err.Error() == errPoolTimeout {
+			return true
+		}
 	}
+	return false
+}
+
+// unavailablePrefixes 表示服务端暂时不可用的 Redis 错误前缀
+var unavailablePrefixes = []string{
+	"LOADING ",
+	"READONLY ",
+	"MASTERDOWN ",
+	"CLUSTERDOWN ",
+	"TRYAGAIN ",
+	"ERR max number of clients reached",
+}
 
-	// 网络错误
-	if strings.Contains(err.Error(), "connection refused") {
-		return errors.New(errors.CodeServerError, "connection refused").WithDetails(operation)
+// isUnavailable 判断是否为连接错误或服务端暂时不可用
+func isUnavailable(err error) bool {
+	if err == redis.ErrClosed || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if stderrors.Is(err, syscall.ECONNREFUSED) || stderrors.Is(err, syscall.ECONNRESET) {
+		return true
 	}
 
-	// 超时错误
-	if strings.Contains(err.Error(), "timeout") {
-		return errors.New(errors.CodeServerError, "operation timeout").WithDetails(operation)
+	var opErr *net.OpError
+	if stderrors.As(err, &opErr) {
+		return true
 	}
 
-	// 其他错误
-	return errors.New(errors.CodeServerError, err.Error()).WithDetails(operation)
+	var redisErr redis.Error
+	if stderrors.As(err, &redisErr) {
+		msg := redisErr.Error()
+		for _, prefix := range unavailablePrefixes {
+			if strings.HasPrefix(msg, prefix) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // operation 定义 Redis 操作
 type operation struct {
+	ctx       context.Context
 	name      string
 	client    *redis.Client
 	metrics   *metrics.Client
@@ -152,8 +212,9 @@ type operation struct {
 }
 
 // newOperation 创建新的操作
-func (c *Client) newOperation(name string) *operation {
+func (c *Client) newOperation(ctx context.Context, name string) *operation {
 	return &operation{
+		ctx:       ctx,
 		name:      name,
 		client:    c.client,
 		metrics:   c.metrics,
@@ -162,8 +223,10 @@ func (c *Client) newOperation(name string) *operation {
 	}
 }
 
-// end 结束操作并记录指标
+// end 结束操作并记录日志和指标
 func (op *operation) end(err error) {
+	op.log(err)
+
 	if !op.config.EnableMetrics || op.metrics == nil {
 		return
 	}
@@ -183,9 +246,28 @@ func (op *operation) end(err error) {
 	).WithLabelValues(op.name, getStatus(err), op.config.ServiceName).Inc()
 }
 
+// log 记录操作日志，日志会附带上下文中的请求ID等字段
+// 仅在 debug 级别下输出，失败时的错误由调用方处理和记录，避免重复输出
+func (op *operation) log(err error) {
+	if !logger.LoggerFromContext(op.ctx).IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	entry := logger.Ctx(op.ctx).WithFields(logger.Fields{
+		"client":      op.config.ServiceName,
+		"operation":   op.name,
+		"duration_ms": float64(time.Since(op.startTime).Nanoseconds()) / 1e6,
+	})
+	if err != nil && err != redis.Nil {
+		entry.WithError(err).Debug("Redis 操作失败")
+		return
+	}
+	entry.Debug("Redis 操作完成")
+}
+
 // Get 获取值
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
-	op := c.newOperation("get")
+	op := c.newOperation(ctx, "get")
 	result, err := c.client.Get(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -196,7 +278,7 @@ func (c *Client) Get(ctx context.Context, key string) (string, error) {
 
 // Set 设置值
 func (c *Client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	op := c.newOperation("set")
+	op := c.newOperation(ctx, "set")
 	err := c.client.Set(ctx, key, value, expiration).Err()
 	op.end(err)
 	if err != nil {
@@ -207,7 +289,7 @@ func (c *Client) Set(ctx context.Context, key string, value interface{}, expirat
 
 // Del 删除键
 func (c *Client) Del(ctx context.Context, keys ...string) error {
-	op := c.newOperation("del")
+	op := c.newOperation(ctx, "del")
 	err := c.client.Del(ctx, keys...).Err()
 	op.end(err)
 	if err != nil {
@@ -218,7 +300,7 @@ func (c *Client) Del(ctx context.Context, keys ...string) error {
 
 // Exists 检查键是否存在
 func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
-	op := c.newOperation("exists")
+	op := c.newOperation(ctx, "exists")
 	result, err := c.client.Exists(ctx, keys...).Result()
 	op.end(err)
 	if err != nil {
@@ -229,7 +311,7 @@ func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
 
 // Expire 设置过期时间
 func (c *Client) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
-	op := c.newOperation("expire")
+	op := c.newOperation(ctx, "expire")
 	result, err := c.client.Expire(ctx, key, expiration).Result()
 	op.end(err)
 	if err != nil {
@@ -240,7 +322,7 @@ func (c *Client) Expire(ctx context.Context, key string, expiration time.Duratio
 
 // TTL 获取过期时间
 func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
-	op := c.newOperation("ttl")
+	op := c.newOperation(ctx, "ttl")
 	result, err := c.client.TTL(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -251,7 +333,7 @@ func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
 
 // Incr 自增
 func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
-	op := c.newOperation("incr")
+	op := c.newOperation(ctx, "incr")
 	result, err := c.client.Incr(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -262,7 +344,7 @@ func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
 
 // Decr 自减
 func (c *Client) Decr(ctx context.Context, key string) (int64, error) {
-	op := c.newOperation("decr")
+	op := c.newOperation(ctx, "decr")
 	result, err := c.client.Decr(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -273,7 +355,7 @@ func (c *Client) Decr(ctx context.Context, key string) (int64, error) {
 
 // HGet 获取哈希字段值
 func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
-	op := c.newOperation("hget")
+	op := c.newOperation(ctx, "hget")
 	result, err := c.client.HGet(ctx, key, field).Result()
 	op.end(err)
 	if err != nil {
@@ -284,7 +366,7 @@ func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
 
 // HSet 设置哈希字段值
 func (c *Client) HSet(ctx context.Context, key string, values ...interface{}) error {
-	op := c.newOperation("hset")
+	op := c.newOperation(ctx, "hset")
 	err := c.client.HSet(ctx, key, values...).Err()
 	op.end(err)
 	if err != nil {
@@ -295,7 +377,7 @@ func (c *Client) HSet(ctx context.Context, key string, values ...interface{}) er
 
 // HDel 删除哈希字段
 func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
-	op := c.newOperation("hdel")
+	op := c.newOperation(ctx, "hdel")
 	err := c.client.HDel(ctx, key, fields...).Err()
 	op.end(err)
 	if err != nil {
@@ -306,7 +388,7 @@ func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
 
 // HGetAll 获取所有哈希字段
 func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	op := c.newOperation("hgetall")
+	op := c.newOperation(ctx, "hgetall")
 	result, err := c.client.HGetAll(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -317,7 +399,7 @@ func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, er
 
 // LPush 列表头部插入
 func (c *Client) LPush(ctx context.Context, key string, values ...interface{}) error {
-	op := c.newOperation("lpush")
+	op := c.newOperation(ctx, "lpush")
 	err := c.client.LPush(ctx, key, values...).Err()
 	op.end(err)
 	if err != nil {
@@ -328,7 +410,7 @@ func (c *Client) LPush(ctx context.Context, key string, values ...interface{}) e
 
 // RPush 列表尾部插入
 func (c *Client) RPush(ctx context.Context, key string, values ...interface{}) error {
-	op := c.newOperation("rpush")
+	op := c.newOperation(ctx, "rpush")
 	err := c.client.RPush(ctx, key, values...).Err()
 	op.end(err)
 	if err != nil {
@@ -339,7 +421,7 @@ func (c *Client) RPush(ctx context.Context, key string, values ...interface{}) e
 
 // LPop 列表头部弹出
 func (c *Client) LPop(ctx context.Context, key string) (string, error) {
-	op := c.newOperation("lpop")
+	op := c.newOperation(ctx, "lpop")
 	result, err := c.client.LPop(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -350,7 +432,7 @@ func (c *Client) LPop(ctx context.Context, key string) (string, error) {
 
 // RPop 列表尾部弹出
 func (c *Client) RPop(ctx context.Context, key string) (string, error) {
-	op := c.newOperation("rpop")
+	op := c.newOperation(ctx, "rpop")
 	result, err := c.client.RPop(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -361,7 +443,7 @@ func (c *Client) RPop(ctx context.Context, key string) (string, error) {
 
 // LLen 获取列表长度
 func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
-	op := c.newOperation("llen")
+	op := c.newOperation(ctx, "llen")
 	result, err := c.client.LLen(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -372,7 +454,7 @@ func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
 
 // SAdd 集合添加成员
 func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) error {
-	op := c.newOperation("sadd")
+	op := c.newOperation(ctx, "sadd")
 	err := c.client.SAdd(ctx, key, members...).Err()
 	op.end(err)
 	if err != nil {
@@ -383,7 +465,7 @@ func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) e
 
 // SRem 集合移除成员
 func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) error {
-	op := c.newOperation("srem")
+	op := c.newOperation(ctx, "srem")
 	err := c.client.SRem(ctx, key, members...).Err()
 	op.end(err)
 	if err != nil {
@@ -394,7 +476,7 @@ func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) e
 
 // SMembers 获取集合所有成员
 func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
-	op := c.newOperation("smembers")
+	op := c.newOperation(ctx, "smembers")
 	result, err := c.client.SMembers(ctx, key).Result()
 	op.end(err)
 	if err != nil {
@@ -405,7 +487,7 @@ func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
 
 // SIsMember 判断成员是否在集合中
 func (c *Client) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
-	op := c.newOperation("sismember")
+	op := c.newOperation(ctx, "sismember")
 	result, err := c.client.SIsMember(ctx, key, member).Result()
 	op.end(err)
 	if err != nil {
@@ -422,7 +504,7 @@ func (c *Client) RedisClient() *redis.Client {
 
 // ZAdd 有序集合添加成员
 func (c *Client) ZAdd(ctx context.Context, key string, members ...*redis.Z) error {
-	op := c.newOperation("zadd")
+	op := c.newOperation(ctx, "zadd")
 	err := c.client.ZAdd(ctx, key, members...).Err()
 	op.end(err)
 	if err != nil {
@@ -433,7 +515,7 @@ func (c *Client) ZAdd(ctx context.Context, key string, members ...*redis.Z) erro
 
 // ZRem 有序集合移除成员
 func (c *Client) ZRem(ctx context.Context, key string, members ...interface{}) error {
-	op := c.newOperation("zrem")
+	op := c.newOperation(ctx, "zrem")
 	err := c.client.ZRem(ctx, key, members...).Err()
 	op.end(err)
 	if err != nil {
@@ -444,7 +526,7 @@ func (c *Client) ZRem(ctx context.Context, key string, members ...interface{}) e
 
 // ZRange 获取有序集合成员
 func (c *Client) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	op := c.newOperation("zrange")
+	op := c.newOperation(ctx, "zrange")
 	result, err := c.client.ZRange(ctx, key, start, stop).Result()
 	op.end(err)
 	if err != nil {
@@ -455,7 +537,7 @@ func (c *Client) ZRange(ctx context.Context, key string, start, stop int64) ([]s
 
 // ZRangeWithScores 获取有序集合成员及分数
 func (c *Client) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
-	op := c.newOperation("zrange_with_scores")
+	op := c.newOperation(ctx, "zrange_with_scores")
 	result, err := c.client.ZRangeWithScores(ctx, key, start, stop).Result()
 	op.end(err)
 	if err != nil {
@@ -466,7 +548,7 @@ func (c *Client) ZRangeWithScores(ctx context.Context, key string, start, stop i
 
 // Publish 发布消息
 func (c *Client) Publish(ctx context.Context, channel string, message interface{}) error {
-	op := c.newOperation("publish")
+	op := c.newOperation(ctx, "publish")
 	err := c.client.Publish(ctx, channel, message).Err()
 	op.end(err)
 	if err != nil {
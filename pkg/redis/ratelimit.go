@@ -0,0 +1,229 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 限流算法
+const (
+	AlgorithmSlidingLog  = "sliding_log"  // 滑动窗口日志，精确但每个请求占用一个有序集合成员
+	AlgorithmFixedWindow = "fixed_window" // 固定窗口计数，开销最小，窗口边界处可能出现两倍突发
+	AlgorithmGCRA        = "gcra"         // 通用信元速率算法（令牌桶），平滑限流并允许 Burst 突发
+)
+
+// 限流脚本，时间统一取 Redis 服务端时间（微秒），避免各实例时钟不一致
+// 返回值为 {是否允许, 剩余请求数, 配额完全恢复的微秒数, 建议重试等待的微秒数}
+var (
+	// KEYS[1] 限流键；ARGV[1] 窗口内请求数，ARGV[2] 窗口微秒数，ARGV[3] 本次请求的唯一成员
+	slidingLogScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', string.format('%d', now - window))
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, string.format('%d', now), ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+if count == 0 then
+	return {allowed, limit, 0, 0}
+end
+redis.call('PEXPIRE', key, math.ceil(window / 1000))
+
+local oldest = tonumber(redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')[2])
+local newest = tonumber(redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')[2])
+local retry = 0
+if allowed == 0 then
+	retry = oldest + window - now
+end
+return {allowed, limit - count, newest + window - now, retry}
+`)
+
+	// KEYS[1] 限流键；ARGV[1] 窗口内请求数，ARGV[2] 窗口微秒数
+	// 计数和所属窗口的起点保存在同一个哈希中，窗口切换时重新计数，脚本只访问 KEYS 中声明的键
+	fixedWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local start = now - (now % window)
+local reset = start + window - now
+
+local current = redis.call('HMGET', key, 'start', 'count')
+local count = 0
+if tonumber(current[1]) == start then
+	count = tonumber(current[2]) or 0
+end
+if count >= limit then
+	return {0, 0, reset, reset}
+end
+count = count + 1
+redis.call('HSET', key, 'start', string.format('%d', start), 'count', count)
+redis.call('PEXPIRE', key, math.ceil(reset / 1000))
+return {1, limit - count, reset, 0}
+`)
+
+	// KEYS[1] 限流键；ARGV[1] 突发请求数，ARGV[2] 每个请求的间隔微秒数
+	gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call('GET', key))
+if not tat or tat < now then
+	tat = now
+end
+local tolerance = interval * burst
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, tat - now, allow_at - now}
+end
+
+redis.call('SET', key, string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now + tolerance - new_tat) / interval), new_tat - now, 0}
+`)
+)
+
+// RateLimitConfig 分布式限流配置
+type RateLimitConfig struct {
+	// 限流算法，默认 AlgorithmGCRA
+	Algorithm string
+	// 每个窗口允许的请求数
+	Limit int
+	// 窗口长度，默认 1 秒
+	Window time.Duration
+	// 突发请求数，仅 AlgorithmGCRA 使用，默认与 Limit 相同
+	Burst int
+	// 限流键前缀，默认 "ratelimit:<算法>:"，配额不同的限流器应使用不同的前缀
+	Prefix string
+}
+
+// RateLimitResult 限流检查结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否允许请求
+	Limit      int           // 窗口内允许的请求数，GCRA 为突发请求数
+	Remaining  int           // 剩余可用请求数
+	Reset      time.Duration // 配额完全恢复所需的时间
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
+
+// RateLimiter 基于 Redis Lua 脚本的分布式限流器
+// 每次检查都是一次原子的脚本调用，多个服务实例共享同一份配额
+type RateLimiter struct {
+	client *Client
+	config RateLimitConfig
+	script *redis.Script
+}
+
+// NewRateLimiter 创建分布式限流器
+func NewRateLimiter(client *Client, config *RateLimitConfig) (*RateLimiter, error) {
+	if client == nil {
+		return nil, fmt.Errorf("ratelimit: client is nil")
+	}
+	if config == nil || config.Limit <= 0 {
+		return nil, fmt.Errorf("ratelimit: limit must be positive")
+	}
+
+	cfg := *config
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmGCRA
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Second
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Limit
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "ratelimit:" + cfg.Algorithm + ":"
+	}
+
+	l := &RateLimiter{client: client, config: cfg}
+	switch cfg.Algorithm {
+	case AlgorithmSlidingLog:
+		l.script = slidingLogScript
+	case AlgorithmFixedWindow:
+		l.script = fixedWindowScript
+	case AlgorithmGCRA:
+		l.script = gcraScript
+		if cfg.Window/time.Duration(cfg.Limit) < time.Microsecond {
+			return nil, fmt.Errorf("ratelimit: window %s is too short for limit %d", cfg.Window, cfg.Limit)
+		}
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown algorithm %q", cfg.Algorithm)
+	}
+	return l, nil
+}
+
+// Config 返回生效的限流配置
+func (l *RateLimiter) Config() RateLimitConfig {
+	return l.config
+}
+
+// Allow 检查键对应的请求是否超过限流，允许时会占用一次配额
+func (l *RateLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	cfg := l.config
+	window := cfg.Window.Microseconds()
+
+	var args []interface{}
+	limit := cfg.Limit
+	switch cfg.Algorithm {
+	case AlgorithmSlidingLog:
+		member, err := randomMember()
+		if err != nil {
+			return nil, err
+		}
+		args = []interface{}{cfg.Limit, window, member}
+	case AlgorithmFixedWindow:
+		args = []interface{}{cfg.Limit, window}
+	default:
+		args = []interface{}{cfg.Burst, window / int64(cfg.Limit)}
+		limit = cfg.Burst
+	}
+
+	name := "ratelimit_" + cfg.Algorithm
+	op := l.client.newOperation(ctx, name)
+	values, err := l.script.Run(ctx, l.client.client, []string{cfg.Prefix + key}, args...).Int64Slice()
+	op.end(err)
+	if err != nil {
+		return nil, wrapError(err, name)
+	}
+	if len(values) != 4 {
+		return nil, wrapError(fmt.Errorf("unexpected script result %v", values), name)
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// randomMember 生成滑动窗口日志中的唯一成员，避免同一微秒内的请求互相覆盖
+func randomMember() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}